@@ -1,8 +1,11 @@
 package logger
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"strings"
 )
@@ -14,21 +17,66 @@ const (
 	LogLevelError
 )
 
+// LogLevelTrace is the most verbose level, below LogLevelDebug.
+// It is defined separately so the values of the other levels stay unchanged.
+const LogLevelTrace = LogLevelDebug - 1
+
 type Logger struct {
 	logger *log.Logger
+	slog   *slog.Logger
 	level  int
 	prefix string
 }
 
+// DefaultFlags are the log flags used by NewLogger: date and time with microseconds.
+const DefaultFlags = log.Ldate | log.Ltime | log.Lmicroseconds
+
 func NewLogger(level int, prefix string) *Logger {
+	return NewLoggerWithWriter(level, prefix, os.Stdout)
+}
+
+// NewLoggerWithWriter creates a Logger that writes to w instead of os.Stdout, with DefaultFlags.
+// Use it to route the logs into another pipeline, or to capture them in tests.
+func NewLoggerWithWriter(level int, prefix string, w io.Writer) *Logger {
 	return &Logger{
-		logger: log.New(os.Stdout, "", log.Ldate|log.Ltime|log.Lmicroseconds),
+		logger: log.New(w, "", DefaultFlags),
 		level:  level,
 		prefix: prefix,
 	}
 }
 
+// NewLoggerWithFlags creates a Logger with the given log package flags.
+// Pass 0 to disable timestamps, e.g. when the destination already adds its own.
+func NewLoggerWithFlags(level int, prefix string, flags int) *Logger {
+	return &Logger{
+		logger: log.New(os.Stdout, "", flags),
+		level:  level,
+		prefix: prefix,
+	}
+}
+
+// WithLevel returns a copy of the Logger that logs at the given level.
+// The copy shares the destination and prefix, so a subsystem can be made more or less
+// verbose without creating a new writer.
+func (l *Logger) WithLevel(level int) *Logger {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Enabled reports whether messages at the given level are logged.
+// Use it to skip building expensive log arguments.
+func (l *Logger) Enabled(level int) bool {
+	return l.level <= level
+}
+
 // Log methods with formatting
+func (l *Logger) Trace(format string, v ...interface{}) {
+	if l.level <= LogLevelTrace {
+		l.log("TRACE", format, v...)
+	}
+}
+
 func (l *Logger) Debug(format string, v ...interface{}) {
 	if l.level <= LogLevelDebug {
 		l.log("DEBUG", format, v...)
@@ -54,6 +102,12 @@ func (l *Logger) Error(format string, v ...interface{}) {
 }
 
 // Log methods without formatting (Println-like behavior)
+func (l *Logger) Traceln(v ...interface{}) {
+	if l.level <= LogLevelTrace {
+		l.logln("TRACE", v...)
+	}
+}
+
 func (l *Logger) Debugln(v ...interface{}) {
 	if l.level <= LogLevelDebug {
 		l.logln("DEBUG", v...)
@@ -79,6 +133,10 @@ func (l *Logger) Errorln(v ...interface{}) {
 }
 
 func (l *Logger) log(level, format string, v ...interface{}) {
+	if l.slog != nil {
+		l.slog.Log(context.Background(), slogLevel(level), fmt.Sprintf(format, v...), "type", l.prefix)
+		return
+	}
 	l.logger.Printf("%s: %s %s", level, l.prefix, fmt.Sprintf(format, v...))
 }
 
@@ -87,5 +145,9 @@ func (l *Logger) logln(level string, v ...interface{}) {
 	for i, arg := range v {
 		args[i] = fmt.Sprint(arg)
 	}
+	if l.slog != nil {
+		l.slog.Log(context.Background(), slogLevel(level), strings.Join(args, " "), "type", l.prefix)
+		return
+	}
 	l.logger.Printf("%s: %s %s", level, l.prefix, strings.Join(args, " "))
 }
@@ -7,8 +7,29 @@ import (
 	"strings"
 )
 
+// DefaultPrettyMapDepth is the nesting limit used by PrettyMap.
+const DefaultPrettyMapDepth = 32
+
+// PrettyMap formats a map as indented "key: value" lines, recursing into nested maps
+// up to DefaultPrettyMapDepth levels.
 func PrettyMap(data map[string]interface{}, indent string) string {
+	return PrettyMapDepth(data, indent, DefaultPrettyMapDepth)
+}
+
+// PrettyMapDepth is like PrettyMap but recurses into at most maxDepth levels of nested maps.
+// Deeper maps are replaced by "...(max depth)" and maps that contain themselves by "...(cycle)".
+func PrettyMapDepth(data map[string]interface{}, indent string, maxDepth int) string {
 	var builder strings.Builder
+	prettyMap(&builder, data, indent, maxDepth, map[uintptr]bool{})
+	return builder.String()
+}
+
+func prettyMap(builder *strings.Builder, data map[string]interface{}, indent string, depth int, visiting map[uintptr]bool) {
+	// Track the maps on the current path so a cycle is printed once instead of forever
+	ptr := reflect.ValueOf(data).Pointer()
+	visiting[ptr] = true
+	defer delete(visiting, ptr)
+
 	keys := make([]string, 0, len(data))
 	for k := range data {
 		keys = append(keys, k)
@@ -27,14 +48,22 @@ func PrettyMap(data map[string]interface{}, indent string) string {
 
 		switch reflect.TypeOf(v).Kind() {
 		case reflect.Map:
-			// If it's a map, recursively call PrettyLogMap
+			// If it's a map, recursively call prettyMap
 			subMap, ok := v.(map[string]interface{})
 			if !ok {
 				builder.WriteString(fmt.Sprintf("(Unsupported map type: %T)\n", v))
 				continue
 			}
+			if visiting[reflect.ValueOf(subMap).Pointer()] {
+				builder.WriteString("...(cycle)\n")
+				continue
+			}
+			if depth <= 0 {
+				builder.WriteString("...(max depth)\n")
+				continue
+			}
 			builder.WriteString("\n")
-			builder.WriteString(PrettyMap(subMap, indent+"  "))
+			prettyMap(builder, subMap, indent+"  ", depth-1, visiting)
 		case reflect.Slice, reflect.Array:
 			// Handle slices and arrays
 			builder.WriteString(fmt.Sprintf("%v\n", v))
@@ -43,5 +72,4 @@ func PrettyMap(data map[string]interface{}, indent string) string {
 			builder.WriteString(fmt.Sprintf("%v\n", v))
 		}
 	}
-	return builder.String()
 }
@@ -0,0 +1,34 @@
+package logger
+
+import "log/slog"
+
+// LevelTrace is the slog level used for TRACE messages, one step below slog.LevelDebug.
+const LevelTrace = slog.LevelDebug - 4
+
+// NewSlogLogger creates a Logger that writes through the given slog.Logger instead of the
+// standard log package, so messages are not logged twice. Messages below level are still
+// discarded before reaching slog; the slog handler may filter further. The prefix is attached
+// to every record as the "type" attribute.
+func NewSlogLogger(level int, prefix string, s *slog.Logger) *Logger {
+	return &Logger{
+		slog:   s,
+		level:  level,
+		prefix: prefix,
+	}
+}
+
+// slogLevel maps the level names used by Logger to slog levels.
+func slogLevel(level string) slog.Level {
+	switch level {
+	case "TRACE":
+		return LevelTrace
+	case "DEBUG":
+		return slog.LevelDebug
+	case "INFO":
+		return slog.LevelInfo
+	case "WARN":
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWithLevel(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: LevelTrace})
+	parent := NewSlogLogger(LogLevelWarn, "Item", slog.New(handler))
+	child := parent.WithLevel(LogLevelDebug)
+
+	parent.Debugln("parent debug")
+	child.Debugln("child debug")
+
+	output := buf.String()
+	if strings.Contains(output, "parent debug") {
+		t.Errorf("Parent logged below its level:\n%s", output)
+	}
+	if !strings.Contains(output, `msg="child debug" type=Item`) {
+		t.Errorf("Child did not log to the shared destination with the shared prefix:\n%s", output)
+	}
+	if parent.Enabled(LogLevelDebug) || !child.Enabled(LogLevelDebug) {
+		t.Error("WithLevel changed the parent's level or did not set the child's")
+	}
+}
+
+func TestNewLoggerWithWriter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLoggerWithWriter(LogLevelInfo, "Item", &buf)
+
+	logger.Debugln("hidden")
+	logger.Infoln("shown", 1)
+
+	output := buf.String()
+	if strings.Contains(output, "hidden") {
+		t.Errorf("Logger wrote below its level:\n%s", output)
+	}
+	if !strings.HasSuffix(output, "INFO: Item shown 1\n") {
+		t.Errorf("Logger did not write the message to the writer:\n%s", output)
+	}
+}
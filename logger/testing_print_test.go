@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrettyMap(t *testing.T) {
+	data := map[string]interface{}{
+		"b": 2,
+		"a": map[string]interface{}{"c": "x"},
+		"d": nil,
+	}
+
+	expected := "a: \n  c: x\nb: 2\nd: nil\n"
+	if result := PrettyMap(data, ""); result != expected {
+		t.Errorf("PrettyMap returned %q, expected %q", result, expected)
+	}
+}
+
+func TestPrettyMapCycle(t *testing.T) {
+	data := map[string]interface{}{"name": "root"}
+	data["self"] = data
+
+	expected := "name: root\nself: ...(cycle)\n"
+	if result := PrettyMap(data, ""); result != expected {
+		t.Errorf("PrettyMap returned %q, expected %q", result, expected)
+	}
+}
+
+func TestPrettyMapMaxDepth(t *testing.T) {
+	data := map[string]interface{}{}
+	current := data
+	for i := 0; i < 1000; i++ {
+		next := map[string]interface{}{}
+		current["next"] = next
+		current = next
+	}
+
+	result := PrettyMapDepth(data, "", 3)
+	if lines := strings.Count(result, "\n"); lines != 4 {
+		t.Errorf("PrettyMapDepth returned %d lines, expected 4:\n%s", lines, result)
+	}
+	if !strings.HasSuffix(result, "next: ...(max depth)\n") {
+		t.Errorf("PrettyMapDepth returned %q, expected a max depth marker", result)
+	}
+
+	// The default depth must also stop well before the end of the chain
+	if result := PrettyMap(data, ""); !strings.Contains(result, "...(max depth)") {
+		t.Errorf("PrettyMap did not truncate a map nested 1000 levels deep")
+	}
+}
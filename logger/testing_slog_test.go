@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	l := NewSlogLogger(LogLevelTrace, "Item", slog.New(handler))
+
+	l.Debugln("hidden by the handler")
+	l.Warnln("buffer", "is full")
+	l.Error("code %d", 7)
+
+	output := buf.String()
+	if strings.Contains(output, "hidden") {
+		t.Errorf("Debug message passed an INFO handler:\n%s", output)
+	}
+	if !strings.Contains(output, `level=WARN msg="buffer is full" type=Item`) {
+		t.Errorf("Warn message missing or malformed:\n%s", output)
+	}
+	if !strings.Contains(output, `level=ERROR msg="code 7" type=Item`) {
+		t.Errorf("Error message missing or malformed:\n%s", output)
+	}
+}
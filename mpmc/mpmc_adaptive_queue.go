@@ -0,0 +1,129 @@
+package mpmc
+
+import "sync"
+
+// adaptiveQueue is a ring buffer whose capacity grows when it fills up and shrinks when it
+// drains, bounded by min and max. It sits between dispatch and a Consumer's Messages channel,
+// because Go channels cannot be resized.
+type adaptiveQueue[T any] struct {
+	mu    sync.Mutex
+	items []T
+	head  int
+	count int
+	min   int
+	max   int
+	ready chan struct{}
+}
+
+// newAdaptiveQueue creates a new adaptiveQueue starting at the minimum capacity.
+func newAdaptiveQueue[T any](min, max uint) *adaptiveQueue[T] {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return &adaptiveQueue[T]{
+		items: make([]T, min),
+		min:   int(min),
+		max:   int(max),
+		ready: make(chan struct{}, 1),
+	}
+}
+
+// push appends an item, doubling the capacity up to max if the queue is full.
+// It reports false if the queue is full at its maximum capacity.
+func (q *adaptiveQueue[T]) push(item T) bool {
+	q.mu.Lock()
+	if q.count == len(q.items) {
+		if len(q.items) == q.max {
+			q.mu.Unlock()
+			return false
+		}
+		size := len(q.items) * 2
+		if size > q.max {
+			size = q.max
+		}
+		q.resize(size)
+	}
+	q.items[(q.head+q.count)%len(q.items)] = item
+	q.count++
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// pop removes the oldest item, halving the capacity down to min once the queue is less than a quarter full.
+// It reports false if the queue is empty.
+func (q *adaptiveQueue[T]) pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.count == 0 {
+		return item, false
+	}
+
+	var zero T
+	item = q.items[q.head]
+	q.items[q.head] = zero
+	q.head = (q.head + 1) % len(q.items)
+	q.count--
+
+	if q.count < len(q.items)/4 && len(q.items) > q.min {
+		size := len(q.items) / 2
+		if size < q.min {
+			size = q.min
+		}
+		q.resize(size)
+	}
+	return item, true
+}
+
+// resize moves the queued items into a new ring of the given size.
+// It must be called with mu held and size must be at least count.
+func (q *adaptiveQueue[T]) resize(size int) {
+	items := make([]T, size)
+	for i := 0; i < q.count; i++ {
+		items[i] = q.items[(q.head+i)%len(q.items)]
+	}
+	q.items = items
+	q.head = 0
+}
+
+// len returns the number of queued items.
+func (q *adaptiveQueue[T]) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.count
+}
+
+// cap returns the current capacity.
+func (q *adaptiveQueue[T]) cap() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// pump moves queued items into the output channel in order until done is closed.
+func (q *adaptiveQueue[T]) pump(done <-chan struct{}, output chan<- T) {
+	for {
+		item, ok := q.pop()
+		if !ok {
+			select {
+			case <-q.ready:
+				continue
+			case <-done:
+				return
+			}
+		}
+
+		select {
+		case output <- item:
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNoConsumers is returned by WriteAllSync when no consumer is attached.
+var ErrNoConsumers = errors.New("no consumers")
+
+// WriteAllSync delivers an item to every consumer attached at the time of the call and blocks
+// until each of them has accepted it into its buffer, so that no consumer misses it because its
+// buffer was full. It is only available under ProducerKind_All and returns ErrUnsupportedKind
+// otherwise. Consumers that close while the item waits for room are skipped. If no consumer is
+// attached the item is dropped and counted like a dispatched item, and ErrNoConsumers is returned.
+// It returns ctx.Err() if ctx is done first, in which case the consumers that had room already
+// hold the item, and the same errors as Write if the Producer does not accept writes.
+// The item is handed to the consumers directly by the caller, without going through the input
+// buffer, so it can overtake items written earlier that are still waiting there. The input filter
+// and the middleware added with Use apply, and run on the calling goroutine.
+func (f *Producer[T]) WriteAllSync(ctx context.Context, item T) error {
+	if f.kind != ProducerKind_All {
+		return ErrUnsupportedKind
+	}
+	e := envelope[T]{item: item}
+	if err := f.admit(&e); err != nil {
+		return err
+	}
+	if f.filtered(e.item) || !f.intercept(&e) {
+		return nil
+	}
+	item = e.item
+
+	f.consumers_mu.Lock()
+	consumers := append(ConsumerList[T](nil), f.consumers...)
+	f.consumers_mu.Unlock()
+	if len(consumers) == 0 {
+		f.logger.Warnln("No consumers available, dropping item")
+		f.dropped(DropNoConsumers, item)
+		return ErrNoConsumers
+	}
+
+	// Consumers with room get the item right away, before waiting for the others
+	seq := f.sequence.Add(1)
+	waiting, delivered := consumers[:0], false
+	for _, consumer := range consumers {
+		if !consumer.offer(item) {
+			waiting = append(waiting, consumer)
+			continue
+		}
+		f.accepted(consumer, seq)
+		delivered = true
+	}
+	for _, consumer := range waiting {
+		accepted, err := f.offerWait(ctx, consumer, item)
+		if err != nil {
+			return err
+		}
+		if accepted {
+			f.accepted(consumer, seq)
+			delivered = true
+		}
+	}
+	if delivered {
+		f.recordDelivery(e.enqueued)
+	}
+	return nil
+}
+
+// offerWait waits until the Consumer accepts an item into its buffer. It reports false if the
+// Consumer closes first, and returns ctx.Err() or ErrProducerClosed if ctx is done or the Producer
+// closes first.
+func (f *Producer[T]) offerWait(ctx context.Context, c *Consumer[T], item T) (bool, error) {
+	if c.queue == nil && c.ranked == nil {
+		select {
+		case c.output <- item:
+			return true, nil
+		case <-c.ctx.Done():
+			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-f.done:
+			return false, ErrProducerClosed
+		}
+	}
+
+	// Queued buffers cannot be waited on, so poll them
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for !c.offer(item) {
+		select {
+		case <-ticker.C:
+		case <-c.ctx.Done():
+			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-f.done:
+			return false, ErrProducerClosed
+		}
+	}
+	return true, nil
+}
+
+// accepted updates a Consumer's counters after it accepted the item with the given sequence
+// number outside of dispatch.
+func (f *Producer[T]) accepted(c *Consumer[T], seq int64) {
+	f.consumers_mu.Lock()
+	c.lastUsed = time.Now()
+	f.consumers_mu.Unlock()
+	c.sequence.Store(seq)
+	c.updateHighWater()
+	c.received.Add(1)
+}
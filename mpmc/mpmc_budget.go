@@ -0,0 +1,50 @@
+package mpmc
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrBudgetExceeded is returned by WriteBudget when the input buffer stays full for the whole budget.
+var ErrBudgetExceeded = errors.New("latency budget exceeded")
+
+// WriteBudget sends an item to the Producer's input channel to be delivered within the given
+// budget, measured from the call. While the input buffer is full it waits for room, and returns
+// ErrBudgetExceeded once the budget is spent. An item that is still waiting in the input buffer
+// when its budget runs out is dropped at dispatch instead of being delivered late.
+// Both cases are counted as DropBudgetExceeded. Like Write, it returns ErrProducerClosed if the
+// Producer is closed.
+func (f *Producer[T]) WriteBudget(item T, budget time.Duration) error {
+	deadline := time.Now().Add(budget)
+	e := envelope[T]{item: item, deadline: deadline}
+	if err := f.admit(&e); err != nil {
+		return err
+	}
+	if f.enqueue(e) {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case f.input <- e:
+		f.checkWatermarks()
+		return nil
+	case <-timer.C:
+		f.logger.Warnln("Producer buffer stayed full for the latency budget, dropping item")
+		f.dropped(DropBudgetExceeded, item)
+		return ErrBudgetExceeded
+	case <-f.done:
+		return ErrProducerClosed
+	}
+}
+
+// expired reports whether an item's latency budget ran out before dispatch, and counts the drop.
+func (f *Producer[T]) expired(e envelope[T]) bool {
+	if e.deadline.IsZero() || time.Now().Before(e.deadline) {
+		return false
+	}
+	f.logger.Warnln("Latency budget exceeded, dropping item")
+	f.dropped(DropBudgetExceeded, e.item)
+	return true
+}
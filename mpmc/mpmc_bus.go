@@ -0,0 +1,70 @@
+package mpmc
+
+import (
+	"context"
+	"sync"
+)
+
+// Bus is a consumer pool shared by several Producers.
+// Items written to any attached Producer are delivered to the pool's consumers using the
+// Bus strategy, as if they had all been written to a single Producer.
+//
+// Closing an attached Producer only detaches it from the Bus: the shared consumers keep
+// receiving items from the other Producers. The shared consumers are closed by Bus.Close.
+type Bus[T any] struct {
+	pool        *Producer[T]
+	buffer_size uint
+}
+
+// NewBus creates a new Bus that delivers to its consumers with the given fanout strategy.
+// The input buffer is shared by all attached Producers, and each attachment buffers up to
+// consumer_buffer_size items between its Producer and the Bus.
+func NewBus[T any](kind ProducerKind, input_buffer_size, consumer_buffer_size uint, options ...Option[T]) *Bus[T] {
+	return &Bus[T]{
+		pool:        NewProducer(kind, input_buffer_size, consumer_buffer_size, options...),
+		buffer_size: consumer_buffer_size,
+	}
+}
+
+// CreateConsumer creates a new Consumer in the shared pool.
+func (b *Bus[T]) CreateConsumer(ctx context.Context) *Consumer[T] {
+	return b.pool.CreateConsumer(ctx)
+}
+
+// Attach connects a Producer to the Bus, so that every item it delivers goes to the shared pool.
+// The Bus takes part in the Producer's strategy like one of its consumers; a Producer without
+// consumers of its own sends every item to the Bus. Items are dropped as usual when the
+// attachment's buffer or the Bus input buffer is full.
+// The Producer is detached when the returned function is called or when it is closed.
+// Items still buffered in the attachment when it is detached are discarded.
+func (b *Bus[T]) Attach(p *Producer[T]) (detach func()) {
+	link := make(chan T, b.buffer_size)
+	remove := p.AddOutputChannel(link)
+	stop := b.pool.AddInput(link)
+
+	detached := make(chan struct{})
+	detachOnce := sync.Once{}
+	detach = func() {
+		detachOnce.Do(func() {
+			remove()
+			stop()
+			close(detached)
+		})
+	}
+
+	p.startGoroutine(func() {
+		select {
+		case <-p.closed:
+			p.logger.Debugln("Producer closed, detaching from Bus")
+			detach()
+		case <-detached:
+		}
+	})
+	return
+}
+
+// Close closes the Bus and all of its shared consumers.
+// Attached Producers stay open and drop the items meant for the Bus.
+func (b *Bus[T]) Close() {
+	b.pool.Close()
+}
@@ -0,0 +1,68 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrNoCorrelation is returned by Call when the Producer was created without WithCorrelation.
+	ErrNoCorrelation = errors.New("no correlation configured")
+	// ErrUnknownCall is returned by Respond when no call is waiting for the correlation ID,
+	// typically because the call already returned.
+	ErrUnknownCall = errors.New("unknown call")
+)
+
+// Call sends a request through the Producer and waits for the matching response.
+// A new correlation ID is stored in the request with the function set by WithCorrelation,
+// the request is delivered by the Producer's strategy, and the receiving consumer is expected
+// to answer with Respond using the same ID.
+// It returns ctx.Err() if ctx is done first, for example on timeout, and the error of the
+// write if the request could not be enqueued. The pending call is removed whenever Call returns,
+// so late responses are rejected by Respond.
+func (f *Producer[T]) Call(ctx context.Context, req T) (resp T, err error) {
+	if f.correlate == nil {
+		return resp, ErrNoCorrelation
+	}
+
+	id := CreateID()
+	reply := make(chan T, 1)
+
+	f.calls_mu.Lock()
+	f.calls[id] = reply
+	f.calls_mu.Unlock()
+
+	defer func() {
+		f.calls_mu.Lock()
+		delete(f.calls, id)
+		f.calls_mu.Unlock()
+	}()
+
+	if err = f.Write(f.correlate(req, id)); err != nil {
+		return
+	}
+
+	select {
+	case resp = <-reply:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-f.done:
+		err = ErrProducerClosed
+	}
+	return
+}
+
+// Respond delivers the response to the Call waiting for the correlation ID.
+// It returns ErrUnknownCall if no Call is waiting for it anymore.
+func (f *Producer[T]) Respond(correlationID string, resp T) error {
+	f.calls_mu.Lock()
+	reply, ok := f.calls[correlationID]
+	delete(f.calls, correlationID)
+	f.calls_mu.Unlock()
+
+	if !ok {
+		return ErrUnknownCall
+	}
+	reply <- resp
+	return nil
+}
@@ -0,0 +1,68 @@
+package mpmc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Moonlight-Companies/gompmc/logger"
+)
+
+// ErrInvalidConfig is returned when a Config cannot be used to build a Producer.
+var ErrInvalidConfig = errors.New("invalid producer config")
+
+// Config is a declarative description of a Producer, suitable for loading from JSON.
+type Config struct {
+	// Kind is the fanout strategy.
+	Kind ProducerKind `json:"kind"`
+	// Fallback is the optional secondary strategy, see WithFallback.
+	Fallback *ProducerKind `json:"fallback,omitempty"`
+	// InputBuffer is the size of the input buffer.
+	InputBuffer uint `json:"input_buffer"`
+	// ConsumerBuffer is the size of each consumer's buffer.
+	ConsumerBuffer uint `json:"consumer_buffer"`
+	// LogLevel is one of the logger.LogLevel constants.
+	LogLevel int `json:"log_level"`
+	// MaxInFlightPerCaller limits WriteAs callers, see WithMaxInFlightPerCaller.
+	MaxInFlightPerCaller uint `json:"max_inflight_per_caller"`
+}
+
+// Validate checks the Config for invalid combinations.
+// It returns an error wrapping ErrInvalidConfig describing the first problem found.
+func (c Config) Validate() error {
+	if !c.Kind.valid() {
+		return fmt.Errorf("%w: unknown kind %d", ErrInvalidConfig, c.Kind)
+	}
+	if c.Fallback != nil && !c.Fallback.valid() {
+		return fmt.Errorf("%w: unknown fallback kind %d", ErrInvalidConfig, *c.Fallback)
+	}
+	if c.LogLevel < logger.LogLevelTrace || c.LogLevel > logger.LogLevelError {
+		return fmt.Errorf("%w: unknown log level %d", ErrInvalidConfig, c.LogLevel)
+	}
+	return nil
+}
+
+// NewProducerFromConfig validates the Config and creates a new Producer from it.
+// Zero buffer sizes are accepted but logged as warnings, since they make every
+// non-blocking write or delivery depend on a receiver being ready at that instant.
+func NewProducerFromConfig[T any](config Config) (*Producer[T], error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	options := []Option[T]{WithLogLevel[T](config.LogLevel)}
+	if config.Fallback != nil {
+		options = append(options, WithFallback[T](*config.Fallback))
+	}
+	if config.MaxInFlightPerCaller > 0 {
+		options = append(options, WithMaxInFlightPerCaller[T](config.MaxInFlightPerCaller))
+	}
+
+	result := NewProducer[T](config.Kind, config.InputBuffer, config.ConsumerBuffer, options...)
+	if config.InputBuffer == 0 {
+		result.logger.Warnln("Producer configured with a zero input buffer")
+	}
+	if config.ConsumerBuffer == 0 {
+		result.logger.Warnln("Producer configured with a zero consumer buffer")
+	}
+	return result, nil
+}
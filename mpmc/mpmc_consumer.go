@@ -2,30 +2,118 @@ package mpmc
 
 import (
 	"context"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Consumer represents a consumer in the MPMC (Multi-Producer Multi-Consumer) system.
+// Its items are received from Messages, which is receive-only because the Producer owns the
+// send side: stop a Consumer with Close rather than by closing its channel.
 type Consumer[T any] struct {
 	id        string
+	name      string
 	owner     *Producer[T]
-	Messages  chan T
+	Messages  <-chan T
+	output    chan<- T
+	queue     *adaptiveQueue[T]
+	ranked    *priorityQueue[T]
+	pending   chan T
+	paced     chan<- T
+	ephemeral bool
+	offered   int64
+	delivered int64
+	interval  time.Duration
 	lastUsed  time.Time
+	createdAt time.Time
+	weight    uint
+	current   int64
+	priority  int
+	shards    map[int]bool
+	tags      map[string]string
+	group     string
+	groupHash uint64
+	joinSeq   uint64
+	sequence  atomic.Int64
+	highWater atomic.Int64
+	received  atomic.Uint64
+	load      atomic.Uint64
+	loadAt    atomic.Int64
+	occupancy [OccupancyBuckets]atomic.Uint64
+	handled   atomic.Uint64
+	handleSum atomic.Int64
+	handleMax atomic.Int64
+	leftover  atomic.Pointer[func(T)]
 	ctx       context.Context
 	cancel    context.CancelFunc
 	closeOnce sync.Once
+	flushOnce sync.Once
+}
+
+// ConsumerStats is a snapshot of a Consumer's counters.
+type ConsumerStats struct {
+	// Received is the total number of items delivered to the Consumer.
+	Received uint64
+	// HighWater is the buffer high-water mark, see BufferHighWater.
+	HighWater int
+	// LastSequence is the sequence number of the last delivered item, see LastSequence.
+	LastSequence int64
+	// Occupancy is the histogram of the buffer's occupancy sampled at the interval set with
+	// WithOccupancySampling, see OccupancyBuckets. It is all zero without sampling.
+	Occupancy [OccupancyBuckets]uint64
+	// HandlerCalls is the number of items or batches handled by RunPool, RunBulk or RunWorkers.
+	// It and the handler durations are zero if timing was disabled with WithoutHandlerTiming.
+	HandlerCalls uint64
+	// HandlerAvg is the average time a handler call took.
+	HandlerAvg time.Duration
+	// HandlerMax is the longest time a handler call took.
+	HandlerMax time.Duration
 }
 
 // newConsumer creates a new Consumer with the given owner, context, and buffer size.
+// When the owner uses an adaptive buffer, the buffer size is ignored and items are queued
+// in an adaptiveQueue that feeds an unbuffered Messages channel. When the owner uses priority
+// buffers, items are queued in a priorityQueue of the buffer size instead.
 // It returns a pointer to the new Consumer.
 func newConsumer[T any](owner *Producer[T], ctx context.Context, consumer_buffer_size uint) (result *Consumer[T]) {
+	messages := make(chan T)
+	if owner.adaptive_max == 0 && owner.item_priority == nil {
+		messages = make(chan T, consumer_buffer_size)
+	}
+	result = newOutputConsumer(owner, ctx, messages)
+	result.Messages = messages
+	if owner.item_priority != nil {
+		result.ranked = newPriorityQueue(consumer_buffer_size, owner.item_priority)
+	} else if owner.adaptive_max > 0 {
+		result.queue = newAdaptiveQueue[T](owner.adaptive_min, owner.adaptive_max)
+	}
+	return
+}
+
+// newOutputConsumer creates a new Consumer that delivers into the given channel instead of its own Messages channel.
+// It returns a pointer to the new Consumer.
+func newOutputConsumer[T any](owner *Producer[T], ctx context.Context, output chan<- T) (result *Consumer[T]) {
+	var id string
+	if owner.id_generator != nil {
+		id = owner.id_generator()
+	} else {
+		var err error
+		if id, err = createID(); err != nil {
+			owner.logger.Warnln("Unable to generate a random consumer ID, using fallback ID:", err)
+			id = createFallbackID()
+		}
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
+	now := time.Now()
 	result = &Consumer[T]{
-		id:        CreateID(),
+		id:        id,
 		owner:     owner,
-		Messages:  make(chan T, consumer_buffer_size),
-		lastUsed:  time.Now(),
+		output:    output,
+		lastUsed:  now,
+		createdAt: now,
+		weight:    1,
 		ctx:       ctx,
 		cancel:    cancel,
 		closeOnce: sync.Once{},
@@ -38,11 +126,367 @@ func (c *Consumer[T]) Id() string {
 	return c.id
 }
 
+// Name returns the name the Consumer was created with by GetOrCreateConsumer, or an empty string.
+func (c *Consumer[T]) Name() string {
+	return c.name
+}
+
+// Weight returns the Consumer's weight for the weighted strategies.
+func (c *Consumer[T]) Weight() uint {
+	return c.weight
+}
+
+// Priority returns the Consumer's priority for the All strategy.
+func (c *Consumer[T]) Priority() int {
+	return c.priority
+}
+
+// ReportLoad reports the Consumer's current load for the ReportedLoad strategy, for example the
+// depth of its own downstream queue. Lower is less loaded. Reports older than the staleness
+// window set with WithLoadStaleAfter are treated as fully loaded, so report periodically.
+func (c *Consumer[T]) ReportLoad(score float64) {
+	c.load.Store(math.Float64bits(score))
+	c.loadAt.Store(time.Now().UnixNano())
+}
+
+// reportedLoad returns the last reported load, or +Inf if none was reported within staleAfter.
+func (c *Consumer[T]) reportedLoad(now time.Time, staleAfter time.Duration) float64 {
+	at := c.loadAt.Load()
+	if at == 0 || now.Sub(time.Unix(0, at)) > staleAfter {
+		return math.Inf(1)
+	}
+	return math.Float64frombits(c.load.Load())
+}
+
+// LastSequence returns the Producer sequence number of the last item delivered to the Consumer.
+// Comparing consecutive values reveals items that went to other consumers or were dropped.
+func (c *Consumer[T]) LastSequence() int64 {
+	return c.sequence.Load()
+}
+
+// Stats returns a snapshot of the Consumer's counters.
+// The counters are kept after Close, so the final totals can be reported during shutdown.
+func (c *Consumer[T]) Stats() ConsumerStats {
+	result := ConsumerStats{
+		Received:     c.received.Load(),
+		HighWater:    c.BufferHighWater(),
+		LastSequence: c.LastSequence(),
+	}
+	for i := range c.occupancy {
+		result.Occupancy[i] = c.occupancy[i].Load()
+	}
+	result.HandlerCalls, result.HandlerAvg, result.HandlerMax = c.handlerStats()
+	return result
+}
+
+// BufferHighWater returns the highest number of items the Consumer's buffer has held
+// since it was created or since the last ResetHighWater.
+// It is measured by the Producer right after each delivery, so a value equal to the
+// buffer capacity means the Consumer came close to dropping items.
+func (c *Consumer[T]) BufferHighWater() int {
+	return int(c.highWater.Load())
+}
+
+// ResetHighWater resets the buffer high-water mark to zero.
+func (c *Consumer[T]) ResetHighWater() {
+	c.highWater.Store(0)
+}
+
+// offer tries to add an item to the Consumer's buffer without blocking.
+// It reports whether the buffer had room for the item.
+func (c *Consumer[T]) offer(item T) bool {
+	if c.ranked != nil {
+		added, evicted, ok := c.ranked.push(item)
+		if ok {
+			c.owner.logger.Warnln("Consumer", c.id, "buffer is full, evicted a lower-priority item")
+			c.owner.dropped(DropConsumerFull, evicted)
+		}
+		return added
+	}
+	if c.queue != nil {
+		return c.queue.push(item)
+	}
+	select {
+	case c.output <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+// offerIfRoom is offer without eviction: a priority buffer that is full refuses the item
+// instead of evicting a buffered one for it.
+func (c *Consumer[T]) offerIfRoom(item T) bool {
+	if c.ranked != nil {
+		return c.ranked.pushIfRoom(item)
+	}
+	return c.offer(item)
+}
+
+// bufferLen returns the number of items waiting in the Consumer's buffer.
+func (c *Consumer[T]) bufferLen() int {
+	if c.ranked != nil {
+		return c.ranked.len() + len(c.output)
+	}
+	if c.queue != nil {
+		return c.queue.len() + len(c.output)
+	}
+	return len(c.output)
+}
+
+// bufferCap returns the current capacity of the Consumer's buffer.
+func (c *Consumer[T]) bufferCap() int {
+	if c.ranked != nil {
+		return c.ranked.size + cap(c.output)
+	}
+	if c.queue != nil {
+		return c.queue.cap() + cap(c.output)
+	}
+	return cap(c.output)
+}
+
+// bufferFree returns the number of items the Consumer's buffer can still take, counting an
+// adaptive buffer at its maximum capacity.
+func (c *Consumer[T]) bufferFree() int {
+	if c.queue != nil {
+		return max(c.queue.max+cap(c.output)-c.bufferLen(), 0)
+	}
+	return max(c.bufferCap()-c.bufferLen(), 0)
+}
+
+// updateHighWater raises the buffer high-water mark to the current buffer length if it is higher.
+func (c *Consumer[T]) updateHighWater() {
+	length := int64(c.bufferLen())
+	for {
+		current := c.highWater.Load()
+		if length <= current || c.highWater.CompareAndSwap(current, length) {
+			return
+		}
+	}
+}
+
+// CreatedAt returns the time at which the Consumer was created.
+func (c *Consumer[T]) CreatedAt() time.Time {
+	return c.createdAt
+}
+
+// DrainBuffered returns all items currently buffered in Messages without blocking.
+// Items that arrive while draining may be included; it never waits for more.
+// It returns nil for consumers created with AddOutputChannel.
+func (c *Consumer[T]) DrainBuffered() (result []T) {
+	for {
+		select {
+		case item := <-c.Messages:
+			result = append(result, item)
+		default:
+			return
+		}
+	}
+}
+
+// Pull waits for the next item from a Producer using ProducerKind_Pull and returns it.
+// Competing pullers each receive different items, in the order the items were written.
+// It returns ErrNotPullMode for other producer kinds, ctx.Err() if ctx is cancelled,
+// or ErrConsumerClosed if the Consumer or its Producer is closed.
+func (c *Consumer[T]) Pull(ctx context.Context) (item T, err error) {
+	if c.owner.kind != ProducerKind_Pull {
+		return item, ErrNotPullMode
+	}
+
+	select {
+	case e := <-c.owner.pull:
+		c.owner.consumers_mu.Lock()
+		c.lastUsed = time.Now()
+		c.owner.consumers_mu.Unlock()
+		c.sequence.Store(e.seq)
+		c.received.Add(1)
+		c.owner.recordDelivery(e.enqueued)
+		e.report(dispatchResult{consumerID: c.id, delivered: 1})
+		return e.item, nil
+	case <-ctx.Done():
+		return item, ctx.Err()
+	case <-c.ctx.Done():
+		return item, ErrConsumerClosed
+	}
+}
+
+// ReceiveMatching reads items from Messages until one satisfies match and returns it.
+// Items that do not match are discarded: they are consumed from the buffer and not returned
+// anywhere, so use it only when the other items are not needed, e.g. to wait for a correlated response.
+// It returns ctx.Err() if ctx is cancelled, or ErrConsumerClosed if the Consumer is closed first.
+func (c *Consumer[T]) ReceiveMatching(ctx context.Context, match func(T) bool) (item T, err error) {
+	for {
+		select {
+		case item = <-c.Messages:
+			if match(item) {
+				return item, nil
+			}
+		case <-ctx.Done():
+			return item, ctx.Err()
+		case <-c.ctx.Done():
+			return item, ErrConsumerClosed
+		}
+	}
+}
+
+// RunPool processes items from Messages with the given number of worker goroutines until
+// a handler returns an error, ctx is cancelled, or the Consumer is closed.
+// It returns the first handler error, after which the remaining workers stop,
+// ctx.Err() if ctx was cancelled, or nil if the Consumer was closed.
+// Items still buffered when RunPool returns are left in Messages.
+func (c *Consumer[T]) RunPool(ctx context.Context, workers int, handle func(T) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	poolCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var firstErr error
+	errOnce := sync.Once{}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case item := <-c.Messages:
+					if err := handleTimed(c, handle, item); err != nil {
+						errOnce.Do(func() {
+							firstErr = err
+							cancel()
+						})
+						return
+					}
+				case <-poolCtx.Done():
+					return
+				case <-c.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// RunBulk processes items from Messages in batches until the handler returns an error,
+// ctx is cancelled, or the Consumer is closed. It waits for one item, adds whatever else is
+// already buffered up to maxBatch items without waiting, and calls handle once per batch.
+// The batch slice is reused, so handle must not keep it after returning.
+// When the Consumer is closed, the items still buffered are handled as final batches.
+// It returns the handler error, ctx.Err() if ctx was cancelled, or nil if the Consumer was closed.
+func (c *Consumer[T]) RunBulk(ctx context.Context, maxBatch int, handle func([]T) error) error {
+	if maxBatch < 1 {
+		maxBatch = 1
+	}
+	batch := make([]T, 0, maxBatch)
+
+	for {
+		select {
+		case item := <-c.Messages:
+			batch = c.fillBatch(append(batch[:0], item), maxBatch)
+			if err := handleTimed(c, handle, batch); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.ctx.Done():
+			for {
+				batch = c.fillBatch(batch[:0], maxBatch)
+				if len(batch) == 0 {
+					return nil
+				}
+				if err := handleTimed(c, handle, batch); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// fillBatch appends items already buffered in Messages to batch, without waiting,
+// until it holds maxBatch items or Messages is empty.
+func (c *Consumer[T]) fillBatch(batch []T, maxBatch int) []T {
+	for len(batch) < maxBatch {
+		select {
+		case item := <-c.Messages:
+			batch = append(batch, item)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// drainPollInterval is how often CloseAndDrain and WaitEmpty check whether the buffer has been emptied.
+const drainPollInterval = time.Millisecond
+
+// CloseAndDrain stops delivering new items to the Consumer, waits until the items already
+// buffered have been read or ctx is done, and then closes the Consumer.
+// Readers must keep reading Messages while it waits; they are not stopped until the Consumer closes.
+// It returns the number of items that were left unread, which are lost.
+func (c *Consumer[T]) CloseAndDrain(ctx context.Context) (remaining int) {
+	c.owner.consumers_mu.Lock()
+	c.owner.removeConsumer(c)
+	c.owner.consumers_mu.Unlock()
+	defer c.Close()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		remaining = c.bufferLen()
+		if remaining == 0 {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			c.owner.logger.Warnln("Consumer", c.id, "closing with", remaining, "items undrained")
+			return
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// WaitEmpty blocks until every item buffered for the Consumer has been read.
+// The buffer is checked while holding the Producer's consumer lock, so no item can be in the
+// middle of being delivered to the Consumer when it returns; an item written afterwards can
+// of course arrive right after. It returns ctx.Err() if ctx is done first, or ErrConsumerClosed
+// if the Consumer is closed with items still buffered.
+func (c *Consumer[T]) WaitEmpty(ctx context.Context) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		c.owner.consumers_mu.Lock()
+		empty := c.bufferLen() == 0
+		c.owner.consumers_mu.Unlock()
+		if empty {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.ctx.Done():
+			return ErrConsumerClosed
+		}
+	}
+}
+
 // Close shuts down the Consumer.
 // It ensures that the close operation is performed only once.
 func (c *Consumer[T]) Close() {
 	c.closeOnce.Do(func() {
-		c.owner.logger.Debugln("Consumer", c.id, "closing")
+		c.owner.logger.Debugln("Consumer", c.id, "closing after receiving", c.received.Load(), "items")
 		c.cancel()
 	})
 }
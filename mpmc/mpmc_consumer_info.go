@@ -0,0 +1,102 @@
+package mpmc
+
+import (
+	"encoding/json"
+	"maps"
+	"sort"
+	"time"
+)
+
+// ConsumerInfo is a point-in-time snapshot of a Consumer's state.
+type ConsumerInfo struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Tags      map[string]string `json:"tags"`
+	CreatedAt time.Time         `json:"created_at"`
+	LastUsed  time.Time         `json:"last_used"`
+	BufferLen int               `json:"buffer_len"`
+	BufferCap int               `json:"buffer_cap"`
+	HighWater int               `json:"high_water"`
+	Received  uint64            `json:"received"`
+}
+
+// info returns a snapshot of the Consumer's state.
+// It must be called with the owner's consumers_mu held.
+func (c *Consumer[T]) info() ConsumerInfo {
+	return ConsumerInfo{
+		ID:        c.id,
+		Name:      c.name,
+		Tags:      maps.Clone(c.tags),
+		CreatedAt: c.createdAt,
+		LastUsed:  c.lastUsed,
+		BufferLen: c.bufferLen(),
+		BufferCap: c.bufferCap(),
+		HighWater: c.BufferHighWater(),
+		Received:  c.received.Load(),
+	}
+}
+
+// ConsumerInfo returns a snapshot of all consumers attached to the Producer.
+// The order follows the Producer's internal list, which some strategies reorder.
+func (f *Producer[T]) ConsumerInfo() []ConsumerInfo {
+	f.consumers_mu.Lock()
+	defer f.consumers_mu.Unlock()
+
+	result := make([]ConsumerInfo, 0, len(f.consumers))
+	for _, consumer := range f.consumers {
+		result = append(result, consumer.info())
+	}
+	return result
+}
+
+// SortedConsumerInfo returns the same snapshot as ConsumerInfo sorted by creation time,
+// then by ID, so repeated calls list the consumers in a stable order.
+func (f *Producer[T]) SortedConsumerInfo() []ConsumerInfo {
+	result := f.ConsumerInfo()
+	sortConsumerInfo(result)
+	return result
+}
+
+// sortConsumerInfo sorts consumer snapshots by creation time, then by ID.
+func sortConsumerInfo(result []ConsumerInfo) {
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+}
+
+// Topology describes a Producer and its consumers for inspection, as returned by TopologyJSON.
+// Like Config, it and the ConsumerInfo and ProducerStats it holds use snake_case JSON keys.
+type Topology struct {
+	Kind               string         `json:"kind"`
+	InputLen           int            `json:"input_len"`
+	InputCap           int            `json:"input_cap"`
+	ConsumerBufferSize uint           `json:"consumer_buffer_size"`
+	Stats              ProducerStats  `json:"stats"`
+	Consumers          []ConsumerInfo `json:"consumers"`
+}
+
+// TopologyJSON returns a JSON document of the Producer's Topology, for admin endpoints and
+// dashboards. The consumers and the input buffer are captured in a single lock hold, so they are
+// consistent with each other; the counters in Stats are read right after.
+// Consumers are listed in the order of SortedConsumerInfo.
+func (f *Producer[T]) TopologyJSON() ([]byte, error) {
+	f.consumers_mu.Lock()
+	result := Topology{
+		Kind:               f.kind.String(),
+		InputLen:           f.inputLen(),
+		InputCap:           cap(f.input),
+		ConsumerBufferSize: f.consumer_buffer_size,
+		Consumers:          make([]ConsumerInfo, 0, len(f.consumers)),
+	}
+	for _, consumer := range f.consumers {
+		result.Consumers = append(result.Consumers, consumer.info())
+	}
+	f.consumers_mu.Unlock()
+
+	result.Stats = f.Stats()
+	sortConsumerInfo(result.Consumers)
+	return json.Marshal(result)
+}
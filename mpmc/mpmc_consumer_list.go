@@ -20,3 +20,52 @@ func (cl ConsumerList[T]) Less(i, j int) bool {
 func (cl ConsumerList[T]) Swap(i, j int) {
 	cl[i], cl[j] = cl[j], cl[i]
 }
+
+// leastRecentlyUsed returns the Consumer that received an item longest ago, the first one on
+// ties, or nil if the list is empty.
+func (cl ConsumerList[T]) leastRecentlyUsed() (selected *Consumer[T]) {
+	for _, consumer := range cl {
+		if selected == nil || consumer.lastUsed.Before(selected.lastUsed) {
+			selected = consumer
+		}
+	}
+	return
+}
+
+// MinBy returns the Consumer with the lowest score, or nil if the list is empty.
+// Ties are broken in favour of the least recently used Consumer.
+// The list must not change while MinBy runs; for a Producer's consumers that means holding its lock.
+func (cl ConsumerList[T]) MinBy(score func(*Consumer[T]) float64) *Consumer[T] {
+	return cl.bestBy(score, func(a, b float64) bool { return a < b })
+}
+
+// MaxBy returns the Consumer with the highest score, or nil if the list is empty.
+// Ties are broken in favour of the least recently used Consumer.
+// The list must not change while MaxBy runs; for a Producer's consumers that means holding its lock.
+func (cl ConsumerList[T]) MaxBy(score func(*Consumer[T]) float64) *Consumer[T] {
+	return cl.bestBy(score, func(a, b float64) bool { return a > b })
+}
+
+// bestBy returns the Consumer whose score is better than all others according to better.
+func (cl ConsumerList[T]) bestBy(score func(*Consumer[T]) float64, better func(a, b float64) bool) (selected *Consumer[T]) {
+	var selectedScore float64
+	for _, consumer := range cl {
+		s := score(consumer)
+		if selected == nil || better(s, selectedScore) || (s == selectedScore && consumer.lastUsed.Before(selected.lastUsed)) {
+			selected = consumer
+			selectedScore = s
+		}
+	}
+	return
+}
+
+// priorityOrder sorts a ConsumerList by priority, highest first.
+type priorityOrder[T any] struct {
+	ConsumerList[T]
+}
+
+// Less reports whether the Consumer with index i has a higher priority than the Consumer with index j.
+// This is part of sort.Interface.
+func (p priorityOrder[T]) Less(i, j int) bool {
+	return p.ConsumerList[i].priority > p.ConsumerList[j].priority
+}
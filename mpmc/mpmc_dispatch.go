@@ -0,0 +1,583 @@
+package mpmc
+
+import (
+	"context"
+	"math/rand"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gompmc/logger"
+)
+
+// envelope carries an item through the input channel together with the metadata of its write.
+// Envelopes are passed by value and live in the input channel's buffer, so they are never
+// allocated on their own; consumers only ever see the item.
+type envelope[T any] struct {
+	item     T
+	caller   string
+	counted  bool
+	replicas int
+	route    string
+	shard    int
+	sharded  bool
+	selector Selector
+	group    string
+	key      string
+	ctx      context.Context
+	tracked  chan dispatchResult
+	seq      int64
+	enqueued time.Time
+	deadline time.Time
+	span     DispatchSpan
+	ready    bool
+}
+
+// dispatchResult describes where a dispatched item went.
+type dispatchResult struct {
+	// consumerID is the first consumer that received the item, empty if it was dropped.
+	consumerID string
+	// delivered is the number of consumers that received the item, counted exactly for
+	// replicated writes and reported as 1 for any other delivered item.
+	delivered int
+	// held is set when the item was kept in the startup buffer or parked for WriteWhenReady,
+	// to be distributed later.
+	held bool
+}
+
+// trackedPool holds the result channels of tracked writes for reuse, saving an allocation per write.
+var trackedPool = sync.Pool{
+	New: func() any {
+		return make(chan dispatchResult, 1)
+	},
+}
+
+// acquireTracked returns an empty result channel for a tracked write.
+func acquireTracked() chan dispatchResult {
+	return trackedPool.Get().(chan dispatchResult)
+}
+
+// releaseTracked returns a result channel to the pool. It must only be called once the channel
+// is empty and the dispatch goroutine will not send to it again: after the result was received,
+// or when the write was rejected. A writer that gives up waiting must not release its channel.
+func releaseTracked(tracked chan dispatchResult) {
+	trackedPool.Put(tracked)
+}
+
+// report tells a waiting writer where the item went, if the write is tracked.
+func (e envelope[T]) report(result dispatchResult) {
+	if e.tracked != nil {
+		e.tracked <- result
+	}
+}
+
+// MaxDispatchRestarts is the number of times the dispatch goroutine is restarted after a panic.
+// After that many restarts the next panic closes the Producer instead, to avoid a crash loop.
+const MaxDispatchRestarts = 10
+
+// goroutine_Producer reads items from the input channel and dispatches them to the consumers.
+// A panic during dispatch is recovered and dispatch restarts, up to MaxDispatchRestarts times.
+func (f *Producer[T]) goroutine_Producer() {
+	defer f.dispatch_wg.Done()
+	f.logger.Debugln("goroutine producer started")
+	// Counted separately from the stats, which can be reset
+	restarts := 0
+	for f.dispatchLoop() {
+		if restarts >= MaxDispatchRestarts {
+			f.logger.Errorln("Dispatch goroutine panicked after", MaxDispatchRestarts, "restarts, closing Producer")
+			f.Close()
+			return
+		}
+		restarts++
+		f.dispatch_restarts.Add(1)
+		f.logger.Warnln("Restarting dispatch goroutine")
+	}
+}
+
+// dispatchLoop runs the dispatch loop until the Producer is closed.
+// It reports true if the loop stopped because of a panic.
+func (f *Producer[T]) dispatchLoop() (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			f.logger.Errorln("Dispatch goroutine panicked:", r, "\n"+string(debug.Stack()))
+			panicked = true
+		}
+	}()
+
+	for {
+		f.pause_mu.Lock()
+		paused, pause_changed := f.paused, f.pause_changed
+		f.pause_mu.Unlock()
+
+		// While paused the input is left alone, so writes buffer up until it is full
+		input := f.input
+		if paused {
+			input = nil
+		}
+
+		select {
+		case e := <-input:
+			if !f.process(e) {
+				f.logger.Debugln("goroutine Producer closing")
+				return false
+			}
+			f.checkWatermarks()
+		case <-pause_changed:
+		case <-f.done:
+			f.logger.Debugln("goroutine Producer closing")
+			return false
+		}
+	}
+}
+
+// process dispatches a single item taken from the input channel.
+// Under the All strategy, further items already waiting in the input channel are dispatched
+// in the same lock hold, up to the dispatch batch size.
+// It reports false if the Producer was closed while processing the item.
+func (f *Producer[T]) process(e envelope[T]) bool {
+	f.prepare(&e)
+
+	if f.kind == ProducerKind_Pull {
+		if f.filtered(e.item) || !f.intercept(&e) || f.expired(e) {
+			e.report(dispatchResult{})
+			return true
+		}
+		// Wait for a puller instead of dispatching to consumer buffers
+		select {
+		case f.pull <- e:
+			return true
+		case <-f.done:
+			e.report(dispatchResult{})
+			return false
+		}
+	}
+
+	f.lockDispatch()
+	defer f.unlockDispatch()
+	f.dispatchTracked(e)
+	if f.kind == ProducerKind_All {
+	batch:
+		for i := 1; i < f.dispatch_batch; i++ {
+			select {
+			case next := <-f.input:
+				f.prepare(&next)
+				f.dispatchTracked(next)
+			default:
+				break batch
+			}
+		}
+	}
+	return true
+}
+
+// dispatchTracked dispatches an item and reports the result to a waiting writer and to its span.
+// If dispatch panics the writer is told that the item was dropped before the panic propagates.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) dispatchTracked(e envelope[T]) {
+	var result dispatchResult
+	e.span = f.startSpan(e)
+	defer func() {
+		// The span of a held item ends when the item is flushed or discarded
+		if e.span != nil && !result.held {
+			e.span.End(result.consumerID, result.delivered)
+		}
+		e.report(result)
+	}()
+	result = f.dispatch(e)
+	if result.delivered > 0 {
+		f.recordDelivery(e.enqueued)
+	}
+}
+
+// prepare does the bookkeeping for an item taken from the input channel before it is dispatched.
+func (f *Producer[T]) prepare(e *envelope[T]) {
+	if e.counted {
+		f.releaseInFlight(e.caller)
+	}
+	e.seq = f.sequence.Add(1)
+}
+
+// dispatch discards items rejected by the input filter or the middleware, or past their latency
+// budget, holds items written before the first consumer if a startup buffer is set and items
+// written with WriteWhenReady while there is no consumer, and distributes the others.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) dispatch(e envelope[T]) dispatchResult {
+	if f.filtered(e.item) || !f.intercept(&e) || f.expired(e) {
+		return dispatchResult{}
+	}
+	if f.hold(e) {
+		return dispatchResult{held: true}
+	}
+	return f.distribute(e)
+}
+
+// distribute delivers an item using the primary strategy, and the fallback strategy if the primary one failed.
+// While dispatch is pinned with PinTo, every item goes to the pinned consumer instead.
+// Replicated writes use the k least recently used consumers instead of the primary strategy,
+// routed writes use the route's strategy on the route's consumers, sharded and selector
+// writes use the primary strategy on the shard's or the matching consumers, and keyed
+// writes go to the group member owning the key.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) distribute(e envelope[T]) dispatchResult {
+	f.remember(e.item)
+	if f.pinned != nil {
+		return f.distributePinned(e)
+	}
+
+	consumers, kind := f.consumers, f.kind
+	if e.route != "" {
+		route, ok := f.routes[e.route]
+		if !ok {
+			f.logger.Warnln("Unknown route", e.route, "dropping item")
+			f.dropped(DropUnknownRoute, e.item)
+			return dispatchResult{}
+		}
+		consumers, kind = f.routeConsumers(route), route.kind
+	}
+	if e.sharded {
+		consumers = f.shardConsumers(e.shard)
+	}
+	if e.selector != nil {
+		consumers = f.selectorConsumers(e.selector)
+	}
+	if e.group != "" {
+		consumers = f.groupMembers(e.group)
+	}
+	if f.manual_removal {
+		consumers = activeConsumers(consumers)
+	}
+
+	if len(consumers) == 0 {
+		f.logger.Warnln("No consumers available, dropping item")
+		f.dropped(DropNoConsumers, e.item)
+		return dispatchResult{}
+	}
+
+	if e.group != "" {
+		if target := f.sendTo(f.select_keyed(consumers, e.key), e.item); target != nil {
+			return dispatchResult{consumerID: target.id, delivered: 1}
+		}
+		f.dropped(DropConsumerFull, e.item)
+		return dispatchResult{}
+	}
+
+	if e.replicas > 0 {
+		if first, delivered := f.deliver_lru_k(consumers, e.item, e.replicas); first != nil {
+			return dispatchResult{consumerID: first.id, delivered: delivered}
+		}
+		kind = ProducerKind_LRU
+	} else if target := f.deliver(kind, consumers, e.item); target != nil {
+		return dispatchResult{consumerID: target.id, delivered: 1}
+	}
+
+	// The All strategy counts every consumer that missed the item itself, so a drop it has
+	// already counted is not counted again when a fallback strategy fails too
+	counted := kind == ProducerKind_All
+	if f.has_fallback {
+		f.logger.Debugln("Primary strategy failed, trying fallback strategy")
+		if target := f.deliver(f.fallback, consumers, e.item); target != nil {
+			return dispatchResult{consumerID: target.id, delivered: 1}
+		}
+		counted = counted || f.fallback == ProducerKind_All
+	}
+	if !counted {
+		f.dropped(DropConsumerFull, e.item)
+	}
+	return dispatchResult{}
+}
+
+// filtered reports whether an item is rejected by the input filter, counting it if so.
+func (f *Producer[T]) filtered(item T) bool {
+	if f.input_filter == nil || f.input_filter(item) {
+		return false
+	}
+	f.drop_counts[DropFiltered].Add(1)
+	return true
+}
+
+// activeConsumers returns the consumers whose context is not done yet.
+func activeConsumers[T any](consumers ConsumerList[T]) ConsumerList[T] {
+	result := make(ConsumerList[T], 0, len(consumers))
+	for _, consumer := range consumers {
+		if consumer.ctx.Err() == nil {
+			result = append(result, consumer)
+		}
+	}
+	return result
+}
+
+// deliver sends an item to the given, non-empty list of consumers according to the given strategy.
+// It returns the consumer that received the item, or the first one for strategies
+// delivering to several consumers, and nil if no consumer received it.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) deliver(kind ProducerKind, consumers ConsumerList[T], item T) *Consumer[T] {
+	if kind == ProducerKind_All {
+		return f.deliver_all(consumers, item)
+	}
+	target := f.selectTarget(kind, consumers)
+	if target == nil {
+		return nil
+	}
+	if kind == ProducerKind_SmoothWeighted {
+		f.advance_smooth_weighted(consumers, target)
+	}
+	if kind == ProducerKind_Single && f.fairness != nil {
+		if f.sendTo(target, item) == nil {
+			return nil
+		}
+		f.fairness.record(target.id)
+		return target
+	}
+	return f.sendTo(target, item)
+}
+
+// selectTarget returns the consumer the given strategy delivers the next item to, without sending it.
+// For the All strategy it returns the first consumer served. The list must not be empty.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) selectTarget(kind ProducerKind, consumers ConsumerList[T]) *Consumer[T] {
+	switch kind {
+	case ProducerKind_Single:
+		return f.select_single(consumers)
+	case ProducerKind_LRU:
+		return f.select_lru(consumers)
+	case ProducerKind_All:
+		return f.select_all(consumers)
+	case ProducerKind_WeightedLRU:
+		return f.select_weighted_lru(consumers)
+	case ProducerKind_ReportedLoad:
+		return f.select_reported_load(consumers)
+	case ProducerKind_SmoothWeighted:
+		return f.select_smooth_weighted(consumers)
+	case ProducerKind_InverseFullness:
+		return f.select_inverse_fullness(consumers)
+	}
+	return nil
+}
+
+// send tries to deliver an item to a consumer without blocking.
+// It reports whether the consumer accepted the item, and sends EventSlowConsumer if an open
+// consumer refused it.
+func (f *Producer[T]) send(consumer *Consumer[T], item T) bool {
+	f.send_attempts.Add(1)
+	if f.order_check != nil {
+		consumer.offered++
+	}
+	if consumer.offer(item) {
+		f.send_accepted.Add(1)
+		if f.order_check != nil {
+			f.checkOrder(consumer)
+		}
+		consumer.lastUsed = time.Now()
+		consumer.sequence.Store(f.sequence.Load())
+		consumer.updateHighWater()
+		consumer.received.Add(1)
+		// Guarded so the item is not boxed into an interface unless tracing is enabled
+		if f.logger.Enabled(logger.LogLevelTrace) {
+			f.logger.Traceln("Delivered item", item, "to consumer", consumer.id)
+		}
+		return true
+	}
+	if consumer.ctx.Err() == nil {
+		f.emit(Event{Kind: EventSlowConsumer, ConsumerID: consumer.id})
+	}
+	return false
+}
+
+// checkOrder calls the hook set with WithOrderCheck if the item just accepted by a consumer does
+// not directly follow the last item it accepted, because items offered in between were refused.
+func (f *Producer[T]) checkOrder(consumer *Consumer[T]) {
+	if expected := consumer.delivered + 1; consumer.offered != expected {
+		f.order_check(consumer.id, expected, consumer.offered)
+	}
+	consumer.delivered = consumer.offered
+}
+
+// sendOrDrop tries to deliver an item to a consumer and logs the drop if the consumer's buffer is full.
+func (f *Producer[T]) sendOrDrop(consumer *Consumer[T], item T) bool {
+	if f.send(consumer, item) {
+		return true
+	}
+	f.logger.Warnln("Consumer buffer is full, dropping item")
+	return false
+}
+
+// sendTo tries to deliver an item to a consumer and returns it if the item was accepted.
+func (f *Producer[T]) sendTo(consumer *Consumer[T], item T) *Consumer[T] {
+	if f.sendOrDrop(consumer, item) {
+		return consumer
+	}
+	return nil
+}
+
+// select_single implements the single consumer fanout strategy.
+func (f *Producer[T]) select_single(consumers ConsumerList[T]) *Consumer[T] {
+	if f.fairness != nil {
+		return f.select_fair(consumers)
+	}
+	return consumers[rand.Intn(len(consumers))]
+}
+
+// select_lru implements the least recently used consumer fanout strategy.
+// It scans for the least recently used consumer instead of sorting, so selection is O(n).
+func (f *Producer[T]) select_lru(consumers ConsumerList[T]) *Consumer[T] {
+	return consumers.leastRecentlyUsed()
+}
+
+// deliver_lru_k delivers an item to the k least recently used consumers that have room for it.
+// It returns the first consumer that received the item and the number of consumers that did.
+func (f *Producer[T]) deliver_lru_k(consumers ConsumerList[T], item T, k int) (first *Consumer[T], delivered int) {
+	// consumers may be the Producer's own list, whose order the other strategies and ConsumerInfo rely on
+	consumers = append(ConsumerList[T](nil), consumers...)
+	sort.Sort(consumers)
+	for _, consumer := range consumers {
+		if delivered == k {
+			break
+		}
+		if f.send(consumer, item) {
+			if first == nil {
+				first = consumer
+			}
+			delivered++
+		}
+	}
+	if delivered < k {
+		f.logger.Warnln("Delivered item to", delivered, "of", k, "consumers")
+		if delivered > 0 {
+			f.dropped(DropConsumerFull, item)
+		}
+	}
+	return
+}
+
+// DefaultWeightedLRUScore is the default score of the WeightedLRU strategy: the consumer's weight
+// multiplied by the seconds since it last received an item. Over time each consumer is selected
+// roughly in proportion to its weight, and a consumer with weight 0 is only selected when no
+// other consumer scores above 0.
+func DefaultWeightedLRUScore(weight uint, idle time.Duration) float64 {
+	return float64(weight) * idle.Seconds()
+}
+
+// select_weighted_lru implements the weighted least recently used consumer fanout strategy.
+// Ties are broken in favour of the least recently used consumer.
+func (f *Producer[T]) select_weighted_lru(consumers ConsumerList[T]) *Consumer[T] {
+	now := time.Now()
+	return consumers.MaxBy(func(consumer *Consumer[T]) float64 {
+		return f.weighted_lru_score(consumer.weight, now.Sub(consumer.lastUsed))
+	})
+}
+
+// select_reported_load implements the reported load fanout strategy.
+// Ties, including consumers whose reports are all stale, are broken in favour of the least recently used consumer.
+func (f *Producer[T]) select_reported_load(consumers ConsumerList[T]) *Consumer[T] {
+	now := time.Now()
+	return consumers.MinBy(func(consumer *Consumer[T]) float64 {
+		return consumer.reportedLoad(now, f.load_stale_after)
+	})
+}
+
+// select_smooth_weighted implements the smooth weighted round-robin fanout strategy, as used by nginx:
+// the consumer with the highest current weight plus its weight is selected, and
+// advance_smooth_weighted then updates the current weights. Ties go to the consumer listed first.
+// Consumers with weight 0 are only selected when no consumer in the list has a positive weight.
+func (f *Producer[T]) select_smooth_weighted(consumers ConsumerList[T]) *Consumer[T] {
+	weighted := false
+	for _, consumer := range consumers {
+		if consumer.weight > 0 {
+			weighted = true
+			break
+		}
+	}
+
+	var selected *Consumer[T]
+	var selectedWeight int64
+	for _, consumer := range consumers {
+		if weighted && consumer.weight == 0 {
+			continue
+		}
+		weight := consumer.current + int64(consumer.weight)
+		if selected == nil || weight > selectedWeight {
+			selected = consumer
+			selectedWeight = weight
+		}
+	}
+	return selected
+}
+
+// select_inverse_fullness implements the inverse fullness fanout strategy: a consumer is selected
+// with a probability proportional to the free space in its buffer. If every buffer is full, a
+// consumer is selected uniformly, and the item is dropped unless a fallback strategy finds room.
+func (f *Producer[T]) select_inverse_fullness(consumers ConsumerList[T]) *Consumer[T] {
+	total := 0
+	for _, consumer := range consumers {
+		total += consumer.bufferFree()
+	}
+	if total == 0 {
+		return consumers[rand.Intn(len(consumers))]
+	}
+	pick := rand.Intn(total)
+	for _, consumer := range consumers {
+		if pick -= consumer.bufferFree(); pick < 0 {
+			return consumer
+		}
+	}
+	// Not reached: only readers change a buffer during selection, and they only free space
+	return consumers[len(consumers)-1]
+}
+
+// advance_smooth_weighted adds each consumer's weight to its current weight and subtracts the total
+// weight from the selected consumer, so every consumer is selected in proportion to its weight.
+func (f *Producer[T]) advance_smooth_weighted(consumers ConsumerList[T], selected *Consumer[T]) {
+	var total int64
+	for _, consumer := range consumers {
+		consumer.current += int64(consumer.weight)
+		total += int64(consumer.weight)
+	}
+	selected.current -= total
+}
+
+// reset_smooth_weighted clears the current weights of the smooth weighted strategy, so that a
+// consumer joining or leaving starts a fresh round instead of inheriting a skewed one.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) reset_smooth_weighted() {
+	for _, consumer := range f.consumers {
+		consumer.current = 0
+	}
+}
+
+// deliver_all implements the all consumers fanout strategy.
+// Consumers are served in order of priority, highest first, once any consumer has a priority.
+// An item that does not fit a consumer's buffer is spilled to that consumer's backup, if one is set.
+func (f *Producer[T]) deliver_all(consumers ConsumerList[T], item T) (first *Consumer[T]) {
+	f.sortByPriority(consumers)
+	for _, consumer := range consumers {
+		target := consumer
+		if !f.send(consumer, item) {
+			target = f.findConsumer(f.backups[consumer.id])
+			if target == nil || !f.send(target, item) {
+				f.logger.Warnln("Consumer buffer is full, dropping item")
+				f.dropped(DropConsumerFull, item)
+				continue
+			}
+			f.logger.Debugln("Consumer", consumer.id, "buffer is full, spilled item to backup", target.id)
+		}
+		if first == nil {
+			first = target
+		}
+	}
+	return
+}
+
+// select_all returns the first consumer served by the all consumers fanout strategy.
+func (f *Producer[T]) select_all(consumers ConsumerList[T]) *Consumer[T] {
+	f.sortByPriority(consumers)
+	return consumers[0]
+}
+
+// sortByPriority orders consumers by priority, highest first, once any consumer has a priority.
+func (f *Producer[T]) sortByPriority(consumers ConsumerList[T]) {
+	if f.prioritized {
+		// Stable, so consumers of equal priority keep their relative order
+		sort.Stable(priorityOrder[T]{consumers})
+	}
+}
@@ -0,0 +1,80 @@
+package mpmc
+
+// DropReason describes why the Producer discarded an item.
+type DropReason int
+
+const (
+	// DropInputFull counts writes rejected because the input buffer was full.
+	DropInputFull DropReason = iota
+	// DropCallerLimit counts writes rejected because the caller reached its in-flight limit.
+	DropCallerLimit
+	// DropNoConsumers counts items dispatched while no consumer was available.
+	DropNoConsumers
+	// DropConsumerFull counts items not delivered because the selected consumer's buffer was full.
+	// Under the All strategy every consumer that misses an item is counted.
+	DropConsumerFull
+	// DropUnknownRoute counts items written to a route that does not exist.
+	DropUnknownRoute
+	// DropFiltered counts items discarded by the input filter set with WithInputFilter.
+	// Filtered items are discarded on purpose and do not trigger WithFailOnDrop.
+	DropFiltered
+	// DropRetryOverflow counts items rejected by WriteRetry because the retry queue was full.
+	DropRetryOverflow
+	// DropClosed counts items discarded from the retry queue or the startup buffer, or parked by
+	// WriteWhenReady, when the Producer closed.
+	DropClosed
+	// DropBudgetExceeded counts items written with WriteBudget that could not be dispatched within their budget.
+	DropBudgetExceeded
+	// DropInvalid counts writes rejected by the validator set with WithValidator.
+	// Invalid items are reported to the writer and do not trigger WithFailOnDrop.
+	DropInvalid
+
+	dropReasonCount
+)
+
+// String returns a short description of the DropReason.
+func (r DropReason) String() string {
+	switch r {
+	case DropInputFull:
+		return "input buffer full"
+	case DropCallerLimit:
+		return "caller limit reached"
+	case DropNoConsumers:
+		return "no consumers"
+	case DropConsumerFull:
+		return "consumer buffer full"
+	case DropUnknownRoute:
+		return "unknown route"
+	case DropFiltered:
+		return "filtered"
+	case DropRetryOverflow:
+		return "retry queue full"
+	case DropClosed:
+		return "producer closed"
+	case DropBudgetExceeded:
+		return "latency budget exceeded"
+	case DropInvalid:
+		return "invalid"
+	}
+	return "unknown"
+}
+
+// DropCounts returns a snapshot of the number of items dropped for each reason since the
+// Producer was created or since the last StatsAndReset. Every reason is present in the map, including those with a count of 0.
+func (f *Producer[T]) DropCounts() map[DropReason]uint64 {
+	result := make(map[DropReason]uint64, dropReasonCount)
+	for reason := DropReason(0); reason < dropReasonCount; reason++ {
+		result[reason] = f.drop_counts[reason].Load()
+	}
+	return result
+}
+
+// dropCount returns the total number of dropped items, not counting filtered items.
+func (f *Producer[T]) dropCount() (total uint64) {
+	for reason := DropReason(0); reason < dropReasonCount; reason++ {
+		if reason != DropFiltered {
+			total += f.drop_counts[reason].Load()
+		}
+	}
+	return
+}
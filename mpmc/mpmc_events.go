@@ -0,0 +1,70 @@
+package mpmc
+
+import "time"
+
+// DefaultEventBuffer is the default number of events buffered for Producer.Events.
+const DefaultEventBuffer = 64
+
+// EventKind identifies the kind of an Event.
+type EventKind int
+
+const (
+	// EventConsumerAdded is sent when a consumer is attached to the Producer.
+	EventConsumerAdded EventKind = iota
+	// EventConsumerRemoved is sent when a consumer is detached from the Producer.
+	EventConsumerRemoved
+	// EventDropped is sent when the Producer drops an item; DropReason says why.
+	EventDropped
+	// EventProducerClosed is sent once the Producer has stopped dispatching and closed its consumers.
+	EventProducerClosed
+	// EventSlowConsumer is sent when a consumer's buffer is full and it refuses an item, which
+	// usually means it reads slower than items are dispatched to it.
+	EventSlowConsumer
+)
+
+// String returns the name of the EventKind.
+func (k EventKind) String() string {
+	switch k {
+	case EventConsumerAdded:
+		return "consumer added"
+	case EventConsumerRemoved:
+		return "consumer removed"
+	case EventDropped:
+		return "dropped"
+	case EventProducerClosed:
+		return "producer closed"
+	case EventSlowConsumer:
+		return "slow consumer"
+	}
+	return "unknown"
+}
+
+// Event describes something that happened to a Producer.
+type Event struct {
+	Kind EventKind
+	Time time.Time
+	// ConsumerID is set for EventConsumerAdded, EventConsumerRemoved and EventSlowConsumer.
+	ConsumerID string
+	// DropReason is set for EventDropped.
+	DropReason DropReason
+}
+
+// Events returns the Producer's lifecycle event stream. The channel buffers up to the size set
+// with WithEventBuffer; when it is full, events are discarded unless WithBlockingEvents is used.
+// The channel is never closed, because consumers can still be removed after EventProducerClosed.
+func (f *Producer[T]) Events() <-chan Event {
+	return f.events
+}
+
+// emit sends an event to the event stream, blocking only if WithBlockingEvents is used.
+func (f *Producer[T]) emit(e Event) {
+	e.Time = time.Now()
+	if f.events_block {
+		f.events <- e
+		return
+	}
+	select {
+	case f.events <- e:
+	default:
+	}
+}
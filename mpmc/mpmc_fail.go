@@ -0,0 +1,49 @@
+package mpmc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDropped is wrapped by the sticky error of a Producer created with WithFailOnDrop
+// once it has dropped an item.
+var ErrDropped = errors.New("item dropped")
+
+// Err returns the sticky error of a Producer created with WithFailOnDrop, wrapping ErrDropped
+// and describing the first dropped item, or nil while no item has been dropped.
+// Without WithFailOnDrop it always returns nil.
+func (f *Producer[T]) Err() error {
+	f.err_mu.Lock()
+	defer f.err_mu.Unlock()
+	return f.err
+}
+
+// Reset clears the sticky error so that writes are accepted again.
+// Items dropped before Reset are not recovered.
+func (f *Producer[T]) Reset() {
+	f.err_mu.Lock()
+	f.err = nil
+	f.err_mu.Unlock()
+	f.logger.Debugln("Producer error reset")
+}
+
+// dropped hands an item dropped for the given reason to the overflow consumer, or, if there is
+// none or it has no room, counts the drop and reports it on the event stream.
+// With WithFailOnDrop the first drop puts the Producer into the failed state.
+func (f *Producer[T]) dropped(reason DropReason, item T) {
+	if f.overflowed(item) {
+		return
+	}
+	f.drop_counts[reason].Add(1)
+	f.emit(Event{Kind: EventDropped, DropReason: reason})
+	if !f.fail_on_drop {
+		return
+	}
+
+	f.err_mu.Lock()
+	if f.err == nil {
+		f.err = fmt.Errorf("%w: %s", ErrDropped, reason)
+		f.logger.Errorln("Producer failed:", f.err)
+	}
+	f.err_mu.Unlock()
+}
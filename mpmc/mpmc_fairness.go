@@ -0,0 +1,63 @@
+package mpmc
+
+import "math/rand"
+
+// fairnessWindow remembers the consumers that received the last deliveries of the Single
+// strategy, so that selection can keep every consumer within its fair share of a window.
+type fairnessWindow struct {
+	recent []string
+	next   int
+	filled int
+	counts map[string]int
+}
+
+// newFairnessWindow creates a fairnessWindow for windows of the given number of deliveries.
+// It remembers one delivery less than the window, the one being selected completing it.
+func newFairnessWindow(window int) *fairnessWindow {
+	return &fairnessWindow{
+		recent: make([]string, window-1),
+		counts: make(map[string]int, window),
+	}
+}
+
+// record remembers a delivery to the consumer with the given ID, forgetting the oldest one.
+func (w *fairnessWindow) record(id string) {
+	if len(w.recent) == 0 {
+		return
+	}
+	if w.filled == len(w.recent) {
+		oldest := w.recent[w.next]
+		if w.counts[oldest]--; w.counts[oldest] == 0 {
+			delete(w.counts, oldest)
+		}
+	} else {
+		w.filled++
+	}
+	w.recent[w.next] = id
+	w.next = (w.next + 1) % len(w.recent)
+	w.counts[id]++
+}
+
+// select_fair picks a random consumer among those that can receive the next item without
+// exceeding their fair share, the window divided by the number of consumers rounded up.
+// The remembered deliveries are one fewer than that share times the number of consumers,
+// so at least one consumer is always below its share.
+func (f *Producer[T]) select_fair(consumers ConsumerList[T]) *Consumer[T] {
+	share := (len(f.fairness.recent) + len(consumers)) / len(consumers)
+	eligible := 0
+	for _, consumer := range consumers {
+		if f.fairness.counts[consumer.id] < share {
+			eligible++
+		}
+	}
+	pick := rand.Intn(eligible)
+	for _, consumer := range consumers {
+		if f.fairness.counts[consumer.id] < share {
+			if pick == 0 {
+				return consumer
+			}
+			pick--
+		}
+	}
+	return nil
+}
@@ -4,9 +4,9 @@ package mpmc
 import (
 	"context"
 	"errors"
-	"math/rand"
-	"sort"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Moonlight-Companies/gompmc/logger"
@@ -17,6 +17,12 @@ var (
 	ErrProducerClosed = errors.New("producer is closed")
 	// ErrBufferFull is returned when the producer's buffer is full and can't accept more items.
 	ErrBufferFull = errors.New("buffer is full")
+	// ErrConsumerClosed is returned when trying to receive from a closed consumer.
+	ErrConsumerClosed = errors.New("consumer is closed")
+	// ErrNotPullMode is returned by Consumer.Pull when the producer does not use ProducerKind_Pull.
+	ErrNotPullMode = errors.New("producer is not in pull mode")
+	// ErrCallerLimit is returned when a caller already has the maximum number of items in flight.
+	ErrCallerLimit = errors.New("caller in-flight limit reached")
 )
 
 // ProducerKind defines the type of fanout strategy used by the producer.
@@ -29,177 +35,966 @@ const (
 	ProducerKind_LRU
 	// ProducerKind_All sends each item to all consumers.
 	ProducerKind_All
+	// ProducerKind_WeightedLRU sends each item to the consumer with the highest weighted idle score,
+	// preferring consumers that are both heavily weighted and have not been used recently.
+	ProducerKind_WeightedLRU
+	// ProducerKind_Pull hands each item directly to the next consumer calling Consumer.Pull.
+	// Items wait in the input buffer until a consumer asks for them, so they are never dropped
+	// for lack of consumers or buffer space; consumer buffers are not used.
+	ProducerKind_Pull
+	// ProducerKind_ReportedLoad sends each item to the consumer with the lowest load reported
+	// through Consumer.ReportLoad. Consumers that have not reported within the staleness window
+	// are treated as fully loaded.
+	ProducerKind_ReportedLoad
+	// ProducerKind_SmoothWeighted sends items to consumers in proportion to their weights using
+	// smooth weighted round-robin, which interleaves deliveries instead of sending bursts to
+	// the heaviest consumer.
+	ProducerKind_SmoothWeighted
+	// ProducerKind_InverseFullness sends each item to a randomly selected consumer, with a
+	// probability proportional to the free space in its buffer, so emptier consumers receive
+	// more items without the busiest ones being starved entirely.
+	ProducerKind_InverseFullness
 )
 
+// ProducerKind_Weighted distributes items in proportion to the weights set with
+// CreateConsumerWeighted. It is ProducerKind_SmoothWeighted: while the consumer list does not
+// change, a consumer with weight 3 receives three items for every item a consumer with weight 1
+// receives, interleaved. The rotation restarts whenever a consumer is added or removed, and
+// routes, shards and selectors rotate over their own subsets, so the ratio is approximate
+// across such changes. A consumer with weight 0 only receives items when no consumer with a
+// positive weight is among the candidates.
+const ProducerKind_Weighted = ProducerKind_SmoothWeighted
+
+// String returns the name of the ProducerKind.
+func (k ProducerKind) String() string {
+	switch k {
+	case ProducerKind_Single:
+		return "single"
+	case ProducerKind_LRU:
+		return "lru"
+	case ProducerKind_All:
+		return "all"
+	case ProducerKind_WeightedLRU:
+		return "weighted_lru"
+	case ProducerKind_Pull:
+		return "pull"
+	case ProducerKind_ReportedLoad:
+		return "reported_load"
+	case ProducerKind_SmoothWeighted:
+		return "smooth_weighted"
+	case ProducerKind_InverseFullness:
+		return "inverse_fullness"
+	}
+	return "unknown"
+}
+
+// valid reports whether the ProducerKind is a known fanout strategy.
+func (k ProducerKind) valid() bool {
+	switch k {
+	case ProducerKind_Single, ProducerKind_LRU, ProducerKind_All, ProducerKind_WeightedLRU, ProducerKind_Pull, ProducerKind_ReportedLoad, ProducerKind_SmoothWeighted,
+		ProducerKind_InverseFullness:
+		return true
+	}
+	return false
+}
+
 // Producer manages the distribution of items to consumers based on a specified strategy.
 type Producer[T any] struct {
 	logger               *logger.Logger
-	input                chan T
+	log_level            int
+	log_flags            int
+	log_slog             *slog.Logger
+	kind                 ProducerKind
+	fallback             ProducerKind
+	has_fallback         bool
+	weighted_lru_score   func(weight uint, idle time.Duration) float64
+	load_stale_after     time.Duration
+	input                chan envelope[T]
+	dispatch_batch       int
+	input_filter         func(T) bool
+	middleware           []func(next func(T)) func(T)
+	middleware_mu        sync.Mutex
+	chain                atomic.Pointer[middlewareChain[T]]
+	order_check          func(consumerID string, expected, got int64)
+	id_generator         func() string
+	latency_histogram    *latencyHistogram
+	overflow             atomic.Pointer[Consumer[T]]
+	consumers_closed     bool
+	fairness             *fairnessWindow
+	watermark_low        int
+	watermark_high       int
+	on_high              func()
+	on_low               func()
+	above_high           atomic.Bool
+	replay               []T
+	replay_next          int
+	replay_filled        int
+	startup              []envelope[T]
+	parked               []envelope[T]
+	started              bool
+	handler_timing_off   bool
+	validator            func(T) error
+	pinned               *Consumer[T]
+	overflow_count       atomic.Uint64
+	send_attempts        atomic.Uint64
+	send_accepted        atomic.Uint64
+	pull                 chan envelope[T]
+	sequence             atomic.Int64
+	max_inflight         uint
+	inflight             map[string]uint
+	inflight_mu          sync.Mutex
 	consumer_buffer_size uint
+	adaptive_min         uint
+	adaptive_max         uint
+	item_priority        func(T) int
 	consumers            ConsumerList[T]
 	consumers_mu         sync.Mutex
+	consumers_changed    chan struct{}
+	manual_removal       bool
+	prioritized          bool
+	named                map[string]*Consumer[T]
+	backups              map[string]string
+	routes               map[string]route
+	hash                 func(key string) uint64
+	hash_modulo          bool
+	group_joins          atomic.Uint64
+	on_rebalance         func(group string, members []string)
+	heartbeat_interval   time.Duration
+	heartbeat            func() T
+	idle_timeout         time.Duration
+	occupancy_interval   time.Duration
+	stats_interval       time.Duration
+	on_idle_close        func()
+	last_write           atomic.Int64
+	correlate            func(item T, correlationID string) T
+	calls                map[string]chan T
+	calls_mu             sync.Mutex
+	paused               bool
+	pause_changed        chan struct{}
+	pause_mu             sync.Mutex
 	done                 chan struct{}
+	closed               chan struct{}
 	closeOnce            sync.Once
+	dispatch_wg          sync.WaitGroup
+	goroutines           atomic.Int64
+	dispatch_restarts    atomic.Uint64
+	delivered            atomic.Uint64
+	draining             atomic.Bool
+	latency_total        atomic.Int64
+	latency_count        atomic.Int64
+	latency_max          atomic.Int64
+	lock_timing          bool
+	lock_acquired        time.Time
+	lock_total           atomic.Int64
+	lock_count           atomic.Int64
+	lock_max             atomic.Int64
+	drop_counts          [dropReasonCount]atomic.Uint64
+	unbounded            *adaptiveQueue[envelope[T]]
+	retry_size           uint
+	retry_min_backoff    time.Duration
+	retry_max_backoff    time.Duration
+	retry_queue          []envelope[T]
+	retry_wake           chan struct{}
+	retry_mu             sync.Mutex
+	tracer               DispatchTracer
+	events               chan Event
+	events_buffer        uint
+	events_block         bool
+	fail_on_drop         bool
+	err                  error
+	err_mu               sync.Mutex
 }
 
 // NewProducer creates a new Producer with the specified fanout strategy and buffer sizes.
+// Optional behaviour can be configured with Options.
 // It returns a pointer to the new Producer.
-func NewProducer[T any](kind ProducerKind, input_buffer_size, consumer_buffer_size uint) (result *Producer[T]) {
+func NewProducer[T any](kind ProducerKind, input_buffer_size, consumer_buffer_size uint, options ...Option[T]) (result *Producer[T]) {
 	result = &Producer[T]{
-		logger:               logger.NewLogger(logger.LogLevelDebug, TypeName[T]()),
-		input:                make(chan T, input_buffer_size),
+		log_level:            logger.LogLevelDebug,
+		log_flags:            logger.DefaultFlags,
+		kind:                 kind,
+		weighted_lru_score:   DefaultWeightedLRUScore,
+		load_stale_after:     DefaultLoadStaleAfter,
+		input:                make(chan envelope[T], input_buffer_size),
+		dispatch_batch:       DefaultDispatchBatch,
+		events_buffer:        DefaultEventBuffer,
+		retry_wake:           make(chan struct{}, 1),
+		pull:                 make(chan envelope[T]),
+		inflight:             map[string]uint{},
 		consumer_buffer_size: consumer_buffer_size,
 		consumers:            ConsumerList[T]{},
 		consumers_mu:         sync.Mutex{},
+		consumers_changed:    make(chan struct{}),
+		named:                map[string]*Consumer[T]{},
+		backups:              map[string]string{},
+		routes:               map[string]route{},
+		hash:                 DefaultHash,
+		calls:                map[string]chan T{},
+		pause_changed:        make(chan struct{}),
 		done:                 make(chan struct{}),
+		closed:               make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option(result)
+	}
+	result.events = make(chan Event, result.events_buffer)
+	if result.log_slog != nil {
+		result.logger = logger.NewSlogLogger(result.log_level, TypeName[T](), result.log_slog)
+	} else {
+		result.logger = logger.NewLoggerWithFlags(result.log_level, TypeName[T](), result.log_flags)
 	}
 
 	result.logger.Debugln("Producer created")
 
-	switch kind {
-	case ProducerKind_Single:
-		go result.goroutine_Producer_single()
-	case ProducerKind_LRU:
-		go result.goroutine_Producer_lru()
-	case ProducerKind_All:
-		go result.goroutine_Producer_all()
+	result.dispatch_wg.Add(1)
+	result.startGoroutine(result.goroutine_Producer)
+	if result.heartbeat != nil && result.kind != ProducerKind_Pull {
+		result.startGoroutine(result.goroutine_Producer_heartbeat)
+	}
+	if result.unbounded != nil {
+		result.startGoroutine(func() {
+			result.unbounded.pump(result.done, result.input)
+		})
+	}
+	if result.retry_size > 0 {
+		result.startGoroutine(result.goroutine_Producer_retry)
+	}
+	if result.idle_timeout > 0 {
+		result.last_write.Store(time.Now().UnixNano())
+		result.startGoroutine(result.goroutine_Producer_idle)
+	}
+	if result.occupancy_interval > 0 {
+		result.startGoroutine(result.goroutine_Producer_occupancy)
+	}
+	if result.stats_interval > 0 {
+		result.startGoroutine(result.goroutine_Producer_stats_logging)
 	}
 
-	go func() {
+	result.startGoroutine(func() {
 		<-result.done
+		// Consumers are only cancelled once dispatch has stopped, so no item is sent to a closing consumer
+		result.dispatch_wg.Wait()
 		result.logger.Debugln("Producer closing, closing all consumers")
 		result.consumers_mu.Lock()
+		// From here on new consumers are closed instead of added, so none is left open, and every
+		// consumer is removed exactly once, by its watcher or below, whichever locks first
+		result.consumers_closed = true
+		result.discardStartup()
 		for _, consumer := range result.consumers {
 			consumer.Close()
 		}
+		if overflow := result.overflow.Load(); overflow != nil {
+			overflow.Close()
+		}
+		var removed ConsumerList[T]
+		if result.manual_removal {
+			// Nobody else is going to remove them
+			removed = append(removed, result.consumers...)
+			for len(result.consumers) > 0 {
+				result.removeConsumer(result.consumers[0])
+			}
+		}
 		result.consumers_mu.Unlock()
+		for _, consumer := range removed {
+			consumer.flushLeftovers()
+		}
+		result.emit(Event{Kind: EventProducerClosed})
+		close(result.closed)
 		result.logger.Debugln("Producer closed")
-	}()
+	})
 
 	return
 }
 
 // Write sends an item to the Producer's input channel.
-// It returns an error if the Producer is closed or if the buffer is full.
+// It returns an error if the Producer is closed or if the buffer is full,
+// and the sticky error if the Producer failed under WithFailOnDrop.
 func (f *Producer[T]) Write(item T) error {
+	return f.write(envelope[T]{item: item})
+}
+
+// WriteTracked sends an item to the Producer's input channel and waits until it has been dispatched.
+// It returns the ID of the consumer that received the item, including deliveries made by the
+// fallback strategy, or an empty string if the item was dropped. Under the All strategy the ID
+// of the first consumer that received the item is returned.
+// It returns an error if the Producer is closed or if the buffer is full.
+func (f *Producer[T]) WriteTracked(item T) (consumerID string, err error) {
+	tracked := acquireTracked()
+	if err = f.write(envelope[T]{item: item, tracked: tracked}); err != nil {
+		releaseTracked(tracked)
+		return
+	}
+
 	select {
-	case f.input <- item:
+	case result := <-tracked:
+		releaseTracked(tracked)
+		consumerID = result.consumerID
+	case <-f.done:
+		err = ErrProducerClosed
+	}
+	return
+}
+
+// WriteToLRU sends an item to the k least recently used consumers, skipping consumers whose
+// buffer is full, and waits until it has been dispatched. This gives k-way replication between
+// the LRU and All strategies regardless of the Producer's own strategy; the fallback strategy
+// only applies when no consumer received the item.
+// It returns the number of consumers that received the item, which is 0 if the item was dropped,
+// the buffer was full or the Producer is closed.
+func (f *Producer[T]) WriteToLRU(item T, k int) int {
+	if k < 1 {
+		return 0
+	}
+
+	tracked := acquireTracked()
+	if err := f.write(envelope[T]{item: item, replicas: k, tracked: tracked}); err != nil {
+		releaseTracked(tracked)
+		return 0
+	}
+
+	select {
+	case result := <-tracked:
+		releaseTracked(tracked)
+		return result.delivered
+	case <-f.done:
+		return 0
+	}
+}
+
+// WriteAs sends an item to the Producer's input channel on behalf of the given caller.
+// When a per-caller limit is configured with WithMaxInFlightPerCaller, it returns ErrCallerLimit
+// if the caller already has that many items waiting in the input buffer. A caller's slot is
+// released as soon as the dispatch goroutine takes the item out of the input buffer,
+// whether the item is then delivered or dropped.
+func (f *Producer[T]) WriteAs(callerID string, item T) error {
+	if !f.acquireInFlight(callerID) {
+		f.logger.Warnln("Caller", callerID, "has too many items in flight, dropping item")
+		f.dropped(DropCallerLimit, item)
+		return ErrCallerLimit
+	}
+
+	err := f.write(envelope[T]{item: item, caller: callerID, counted: f.max_inflight > 0})
+	if err != nil {
+		f.releaseInFlight(callerID)
+	}
+	return err
+}
+
+// WriteContext sends an item to the Producer's input channel, blocking while the input buffer
+// is full, so that a fast writer is slowed down to the dispatch rate instead of having items
+// dropped. It returns nil once the item is in the input buffer, ErrProducerClosed if the
+// Producer is closed or draining when it is called or closes while it waits, or ctx.Err() if
+// ctx is cancelled first. Like Write, it returns the validator's error for invalid items.
+func (f *Producer[T]) WriteContext(ctx context.Context, item T) error {
+	return f.writeContext(ctx, envelope[T]{item: item})
+}
+
+// writeContext sends an envelope to the Producer's input channel, blocking while the input buffer is full.
+func (f *Producer[T]) writeContext(ctx context.Context, e envelope[T]) error {
+	if err := f.admit(&e); err != nil {
+		return err
+	}
+	if f.enqueue(e) {
+		return nil
+	}
+	select {
+	case f.input <- e:
+		f.checkWatermarks()
+		return nil
 	case <-f.done:
-		f.logger.Warnln("Producer is closed, dropping item")
 		return ErrProducerClosed
-	default:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// write sends an envelope to the Producer's input channel without blocking.
+func (f *Producer[T]) write(e envelope[T]) error {
+	if err := f.admit(&e); err != nil {
+		return err
+	}
+	if !f.enqueue(e) {
 		f.logger.Warnln("Producer buffer is full, dropping item")
+		f.dropped(DropInputFull, e.item)
 		return ErrBufferFull
 	}
 	return nil
 }
 
+// admit checks that the Producer accepts writes and that the item is valid, and stamps the envelope with the write time.
+func (f *Producer[T]) admit(e *envelope[T]) error {
+	// Checked first so that no write is accepted once Close has been called,
+	// even while the input buffer still has room
+	select {
+	case <-f.done:
+		f.logger.Warnln("Producer is closed, dropping item")
+		return ErrProducerClosed
+	default:
+	}
+	if f.draining.Load() {
+		f.logger.Warnln("Producer is draining, dropping item")
+		return ErrProducerClosed
+	}
+	if err := f.Err(); err != nil {
+		return err
+	}
+	if err := f.validate(e.item); err != nil {
+		return err
+	}
+
+	e.enqueued = time.Now()
+	if f.idle_timeout > 0 {
+		f.last_write.Store(e.enqueued.UnixNano())
+	}
+	return nil
+}
+
+// enqueue adds an envelope to the input without blocking and reports whether there was room.
+// With WithUnboundedInput there is always room.
+func (f *Producer[T]) enqueue(e envelope[T]) bool {
+	if f.unbounded != nil {
+		if !f.unbounded.push(e) {
+			return false
+		}
+	} else {
+		select {
+		case f.input <- e:
+		default:
+			return false
+		}
+	}
+	f.checkWatermarks()
+	return true
+}
+
+// AddInput merges an additional input channel into the Producer.
+// Every item received from the channel is written as if passed to Write, so items are
+// dropped when the input buffer is full. Merging stops when the returned function is called,
+// when the channel is closed, or when the Producer is closed. The stop function is idempotent
+// and never closes the channel.
+func (f *Producer[T]) AddInput(ch <-chan T) (stop func()) {
+	stopped := make(chan struct{})
+	stopOnce := sync.Once{}
+
+	f.startGoroutine(func() {
+		f.logger.Debugln("Input merge started")
+		for {
+			select {
+			case item, ok := <-ch:
+				if !ok {
+					f.logger.Debugln("Input channel closed, input merge stopping")
+					return
+				}
+				f.Write(item)
+			case <-stopped:
+				f.logger.Debugln("Input merge stopped")
+				return
+			case <-f.done:
+				f.logger.Debugln("Producer closed, input merge stopping")
+				return
+			}
+		}
+	})
+
+	return func() {
+		stopOnce.Do(func() {
+			close(stopped)
+		})
+	}
+}
+
+// WriteTicker writes an item made by makeItem every interval, as if passed to Write, so items are
+// dropped when the input buffer is full. Writing stops when the returned function is called,
+// when ctx is done, or when the Producer is closed; the ticker is stopped in every case.
+// The stop function is idempotent.
+func (f *Producer[T]) WriteTicker(ctx context.Context, interval time.Duration, makeItem func() T) (stop func()) {
+	stopped := make(chan struct{})
+	stopOnce := sync.Once{}
+
+	f.startGoroutine(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		f.logger.Debugln("Ticker writer started")
+		for {
+			select {
+			case <-ticker.C:
+				f.Write(makeItem())
+			case <-stopped:
+				f.logger.Debugln("Ticker writer stopped")
+				return
+			case <-ctx.Done():
+				f.logger.Debugln("Context done, ticker writer stopping")
+				return
+			case <-f.done:
+				f.logger.Debugln("Producer closed, ticker writer stopping")
+				return
+			}
+		}
+	})
+
+	return func() {
+		stopOnce.Do(func() {
+			close(stopped)
+		})
+	}
+}
+
+// WriteWhenReady waits for at least one consumer to be attached and then sends an item
+// to the Producer's input channel, blocking while the input buffer is full.
+// It returns ctx.Err() if the context is cancelled first, or ErrProducerClosed if the Producer closes.
+// The item is never dropped for lack of consumers: if the consumers detach after the wait, it is
+// held at dispatch until the next consumer is attached, or discarded as DropClosed if the
+// Producer closes first.
+func (f *Producer[T]) WriteWhenReady(ctx context.Context, item T) error {
+	if err := f.WaitForConsumers(ctx, 1); err != nil {
+		return err
+	}
+	return f.writeContext(ctx, envelope[T]{item: item, ready: true})
+}
+
+// WaitForConsumers blocks until at least n consumers are attached to the Producer.
+// It returns ctx.Err() if the context is cancelled first, or ErrProducerClosed if the Producer closes.
+func (f *Producer[T]) WaitForConsumers(ctx context.Context, n int) error {
+	for {
+		f.consumers_mu.Lock()
+		count := len(f.consumers)
+		changed := f.consumers_changed
+		f.consumers_mu.Unlock()
+
+		if count >= n {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-f.done:
+			return ErrProducerClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// acquireInFlight reserves an in-flight slot for the given caller.
+// It reports whether a slot was available; without a configured limit it always succeeds.
+func (f *Producer[T]) acquireInFlight(callerID string) bool {
+	if f.max_inflight == 0 {
+		return true
+	}
+
+	f.inflight_mu.Lock()
+	defer f.inflight_mu.Unlock()
+	if f.inflight[callerID] >= f.max_inflight {
+		return false
+	}
+	f.inflight[callerID]++
+	return true
+}
+
+// releaseInFlight frees an in-flight slot previously reserved by acquireInFlight.
+func (f *Producer[T]) releaseInFlight(callerID string) {
+	f.inflight_mu.Lock()
+	defer f.inflight_mu.Unlock()
+	if f.inflight[callerID] <= 1 {
+		delete(f.inflight, callerID)
+	} else {
+		f.inflight[callerID]--
+	}
+}
+
+// notifyConsumersChanged wakes up everyone waiting on a change of the consumer list.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) notifyConsumersChanged() {
+	close(f.consumers_changed)
+	f.consumers_changed = make(chan struct{})
+}
+
 // CreateConsumer creates a new Consumer associated with this Producer.
 // It takes a context for cancellation and returns a pointer to the new Consumer.
 func (f *Producer[T]) CreateConsumer(ctx context.Context) (result *Consumer[T]) {
 	result = newConsumer(f, ctx, f.consumer_buffer_size)
+	f.addConsumer(result)
+	return
+}
+
+// CreateConsumerWeighted creates a new Consumer with the given weight for the weighted strategies.
+// Consumers created with CreateConsumer have a weight of 1.
+func (f *Producer[T]) CreateConsumerWeighted(ctx context.Context, weight uint) (result *Consumer[T]) {
+	result = newConsumer(f, ctx, f.consumer_buffer_size)
+	result.weight = weight
+	f.addConsumer(result)
+	return
+}
+
+// CreateConsumerWithPriority creates a new Consumer with the given priority.
+// Under the All strategy consumers are served in order of priority, highest first, so when
+// buffers are tight higher-priority consumers are the least likely to have items dropped.
+// Consumers created with CreateConsumer have a priority of 0.
+func (f *Producer[T]) CreateConsumerWithPriority(ctx context.Context, priority int) (result *Consumer[T]) {
+	result = newConsumer(f, ctx, f.consumer_buffer_size)
+	result.priority = priority
+	f.addConsumer(result)
+	return
+}
 
+// AddOutputChannel attaches a caller-owned channel to this Producer.
+// The channel takes part in the fanout strategy like any other consumer, and items are
+// dropped for it when it is full. The returned function detaches the channel; it never closes it.
+func (f *Producer[T]) AddOutputChannel(ch chan<- T) (remove func()) {
+	result := newOutputConsumer(f, context.Background(), ch)
+	f.addConsumer(result)
+	return result.Close
+}
+
+// addConsumer adds a Consumer to the Producer and removes it again once its context is done.
+func (f *Producer[T]) addConsumer(result *Consumer[T]) {
 	f.consumers_mu.Lock()
-	f.consumers = append(f.consumers, result)
+	added := f.insertConsumer(result)
 	f.consumers_mu.Unlock()
 
+	f.startConsumer(result, added)
+}
+
+// insertConsumer appends a Consumer to the consumer list unless the Producer has closed its consumers.
+// It reports whether the Consumer was added and must be called with consumers_mu held.
+func (f *Producer[T]) insertConsumer(result *Consumer[T]) bool {
+	if f.consumers_closed {
+		return false
+	}
+	if result.priority != 0 {
+		f.prioritized = true
+	}
+	if result.name != "" {
+		f.named[result.name] = result
+	}
+	f.preload(result)
+	f.consumers = append(f.consumers, result)
+	f.reset_smooth_weighted()
+	f.notifyConsumersChanged()
+	f.emit(Event{Kind: EventConsumerAdded, ConsumerID: result.id})
+	f.rebalanced(result.group)
+	f.flushStartup()
+	f.flushParked()
+	return true
+}
+
+// startConsumer starts the goroutines of a Consumer inserted with insertConsumer,
+// or closes it if it could not be added. It must be called without consumers_mu held.
+func (f *Producer[T]) startConsumer(result *Consumer[T], added bool) {
+	if !added {
+		f.logger.Warnln("Producer is closed, closing consumer", result.id)
+		result.Close()
+		return
+	}
+
 	f.logger.Debugln("Consumer", result.id, "created, adding to Producer")
 
-	go func() {
+	if result.queue != nil {
+		f.startGoroutine(func() {
+			result.queue.pump(result.ctx.Done(), result.output)
+		})
+	}
+	if result.ranked != nil {
+		f.startGoroutine(func() {
+			result.ranked.pump(result.ctx.Done(), result.output)
+		})
+	}
+	if result.pending != nil {
+		f.startGoroutine(func() {
+			result.pace(result.ctx)
+		})
+	}
+
+	if f.manual_removal {
+		return
+	}
+
+	f.startGoroutine(func() {
 		<-result.ctx.Done()
-		f.logger.Debugln("Consumer", result.id, "closed, removing from Producer")
+		// Without a cause, as after Close, the cause is just the context's error
+		if cause := context.Cause(result.ctx); cause != result.ctx.Err() {
+			f.logger.Debugln("Consumer", result.id, "closed, cause:", cause, "removing from Producer")
+		} else {
+			f.logger.Debugln("Consumer", result.id, "closed, removing from Producer")
+		}
 		f.consumers_mu.Lock()
-		for i, consumer := range f.consumers {
-			if consumer == result {
-				f.consumers = append(f.consumers[:i], f.consumers[i+1:]...)
-				break
+		f.removeConsumer(result)
+		f.consumers_mu.Unlock()
+		result.flushLeftovers()
+	})
+}
+
+// GetOrCreateConsumer returns the Consumer created with the given name, or creates it if there is none.
+// Lookup and creation happen atomically, so a client that reconnects with the same name keeps a
+// single subscription. ctx is only used when a new Consumer is created; an existing Consumer keeps
+// its original context. An existing Consumer whose context is already done is replaced by a new one,
+// because it no longer receives items; items left in its buffer stay with the old Consumer.
+func (f *Producer[T]) GetOrCreateConsumer(ctx context.Context, name string) *Consumer[T] {
+	f.consumers_mu.Lock()
+	if existing, ok := f.named[name]; ok {
+		if existing.ctx.Err() == nil {
+			f.consumers_mu.Unlock()
+			return existing
+		}
+		f.removeConsumer(existing)
+	}
+
+	result := newConsumer(f, ctx, f.consumer_buffer_size)
+	result.name = name
+	added := f.insertConsumer(result)
+	f.consumers_mu.Unlock()
+
+	f.startConsumer(result, added)
+	return result
+}
+
+// RemoveConsumer closes the consumer with the given ID and removes it from the Producer.
+// It is the only way to remove consumers when WithManualConsumerRemoval is used.
+// It reports whether a consumer with that ID was attached.
+func (f *Producer[T]) RemoveConsumer(id string) bool {
+	f.consumers_mu.Lock()
+	consumer := f.findConsumer(id)
+	if consumer != nil {
+		f.removeConsumer(consumer)
+	}
+	f.consumers_mu.Unlock()
+
+	if consumer == nil {
+		return false
+	}
+	f.logger.Debugln("Consumer", id, "removed from Producer")
+	consumer.Close()
+	consumer.flushLeftovers()
+	return true
+}
+
+// removeConsumer removes a Consumer from the consumer list, if it is still in it.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) removeConsumer(result *Consumer[T]) {
+	for i, consumer := range f.consumers {
+		if consumer == result {
+			f.consumers = append(f.consumers[:i], f.consumers[i+1:]...)
+			f.reset_smooth_weighted()
+			delete(f.backups, result.id)
+			if result.name != "" && f.named[result.name] == result {
+				delete(f.named, result.name)
 			}
+			f.emit(Event{Kind: EventConsumerRemoved, ConsumerID: result.id})
+			f.rebalanced(result.group)
+			f.notifyConsumersChanged()
+			return
 		}
-		f.consumers_mu.Unlock()
+	}
+}
+
+// startGoroutine runs fn in a new goroutine that is counted by GoroutineCount and ActiveGoroutines.
+func (f *Producer[T]) startGoroutine(fn func()) {
+	f.goroutines.Add(1)
+	activeGoroutines.Add(1)
+	go func() {
+		defer activeGoroutines.Add(-1)
+		defer f.goroutines.Add(-1)
+		fn()
 	}()
+}
 
-	return
+// GoroutineCount returns the number of internal goroutines currently running for the Producer:
+// the dispatch goroutine, the close watcher, one removal watcher per consumer, one per AddInput,
+// and the optional heartbeat goroutine and adaptive buffer pumps.
+// After ShutdownOrdered returns it drops to zero as soon as the last goroutines have exited,
+// which makes goroutine leaks observable in tests.
+func (f *Producer[T]) GoroutineCount() int {
+	return int(f.goroutines.Load())
+}
+
+// Pause stops dispatching without closing the Producer. Consumers stay attached and writes
+// keep succeeding until the input buffer is full, after which they return ErrBufferFull.
+// An item the dispatch goroutine has already taken from the input buffer is still delivered.
+func (f *Producer[T]) Pause() {
+	f.setPaused(true)
+}
+
+// Resume restarts dispatching after Pause, delivering the buffered items in order.
+func (f *Producer[T]) Resume() {
+	f.setPaused(false)
+}
+
+// setPaused changes the paused state and wakes the dispatch goroutine.
+func (f *Producer[T]) setPaused(paused bool) {
+	f.pause_mu.Lock()
+	defer f.pause_mu.Unlock()
+	if f.paused == paused {
+		return
+	}
+	f.logger.Debugln("Producer paused:", paused)
+	f.paused = paused
+	close(f.pause_changed)
+	f.pause_changed = make(chan struct{})
+}
+
+// Sequence returns the sequence number of the most recently dispatched item.
+// Every item taken from the input buffer is numbered, whether it is then delivered or dropped,
+// so the numbers are gap-free and follow dispatch order. It is safe to call while writes are
+// in flight; the value is the one current at the moment of the call and may be overtaken immediately.
+// Persist it and pass it to WithStartSequence to keep numbering monotonic across restarts.
+func (f *Producer[T]) Sequence() int64 {
+	return f.sequence.Load()
+}
+
+// WouldSelect returns the ID of the consumer the Producer's strategy would deliver the item to
+// next, without delivering it or changing any consumer's state. Under the All strategy it returns
+// the first consumer that would be served. It does not check whether the target has room for the
+// item, so the fallback strategy is not considered, and for ProducerKind_Single the answer is
+// random. While dispatch is pinned with PinTo it returns the pinned consumer. The middleware
+// added with Use is not run, so the answer is for the item as given. It reports false
+// if the item would be filtered out by the input filter, if there is no consumer, if the pinned
+// consumer is closed or removed, and for ProducerKind_Pull.
+func (f *Producer[T]) WouldSelect(item T) (consumerID string, ok bool) {
+	if f.kind == ProducerKind_Pull || (f.input_filter != nil && !f.input_filter(item)) {
+		return "", false
+	}
+
+	f.consumers_mu.Lock()
+	defer f.consumers_mu.Unlock()
+
+	if f.pinned != nil {
+		if f.pinnedGone() {
+			return "", false
+		}
+		return f.pinned.id, true
+	}
+
+	// Strategies may reorder the list they are given, so they work on a copy
+	consumers := append(ConsumerList[T]{}, f.consumers...)
+	if f.manual_removal {
+		consumers = activeConsumers(consumers)
+	}
+	if len(consumers) == 0 {
+		return "", false
+	}
+	if target := f.selectTarget(f.kind, consumers); target != nil {
+		return target.id, true
+	}
+	return "", false
+}
+
+// SetBackup pairs a consumer with a backup consumer for the All strategy.
+// When an item does not fit the consumer's buffer it is offered to the backup instead,
+// which means the backup may receive the same item twice. An empty backupID removes the pairing.
+// The pairing is forgotten when the consumer is removed, and ignored while the backup is not attached.
+func (f *Producer[T]) SetBackup(consumerID, backupID string) {
+	f.consumers_mu.Lock()
+	defer f.consumers_mu.Unlock()
+	if backupID == "" {
+		delete(f.backups, consumerID)
+	} else {
+		f.backups[consumerID] = backupID
+	}
+}
+
+// findConsumer returns the attached Consumer with the given ID, or nil if there is none.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) findConsumer(id string) *Consumer[T] {
+	if id == "" {
+		return nil
+	}
+	for _, consumer := range f.consumers {
+		if consumer.id == id {
+			return consumer
+		}
+	}
+	return nil
 }
 
 // Close shuts down the Producer and all associated Consumers.
+// It returns immediately; use ShutdownOrdered to wait for the teardown to finish.
 func (f *Producer[T]) Close() {
 	f.closeOnce.Do(func() {
 		close(f.done)
 	})
 }
 
-// goroutine_Producer_single implements the single consumer fanout strategy.
-func (f *Producer[T]) goroutine_Producer_single() {
-	f.logger.Debugln("goroutine producer single started")
-	for {
+// CloseDraining stops accepting writes, waits until the items already written have been
+// dispatched or ctx is done, and then closes the Producer like Close.
+// It returns the number of items delivered and dropped while draining, and the items that were
+// still waiting in the input when ctx was done. Writes rejected while draining return
+// ErrProducerClosed and are not counted. A paused Producer does not drain until it is resumed,
+// and items parked by WriteRetry are discarded as on Close.
+func (f *Producer[T]) CloseDraining(ctx context.Context) (delivered, dropped int64, leftover []T) {
+	f.draining.Store(true)
+	startDelivered, startDropped := f.delivered.Load(), f.dropCount()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+wait:
+	for !f.inputEmpty() {
 		select {
-		case item := <-f.input:
-			f.consumers_mu.Lock()
-			if len(f.consumers) > 0 {
-				selected := f.consumers[rand.Intn(len(f.consumers))]
-				select {
-				case selected.Messages <- item:
-					selected.lastUsed = time.Now()
-				default:
-					f.logger.Warnln("Consumer buffer is full, dropping item")
-				}
-			} else {
-				f.logger.Warnln("No consumers available, dropping item")
-			}
-			f.consumers_mu.Unlock()
+		case <-ticker.C:
+		case <-ctx.Done():
+			break wait
 		case <-f.done:
-			f.logger.Debugln("goroutine Producer single closing")
-			return
+			break wait
 		}
 	}
-}
 
-// goroutine_Producer_lru implements the least recently used consumer fanout strategy.
-func (f *Producer[T]) goroutine_Producer_lru() {
-	f.logger.Debugln("goroutine producer lru started")
+	f.Close()
+	<-f.closed
+
+	// Dispatch has stopped, so whatever is left in the input stays there
+collect:
 	for {
 		select {
-		case item := <-f.input:
-			f.consumers_mu.Lock()
-			if len(f.consumers) > 0 {
-				sort.Sort(f.consumers)
-				lru := f.consumers[0]
-				select {
-				case lru.Messages <- item:
-					lru.lastUsed = time.Now()
-				default:
-					f.logger.Warnln("Consumer buffer is full, dropping item")
-				}
-			} else {
-				f.logger.Warnln("No consumers available, dropping item")
-			}
-			f.consumers_mu.Unlock()
-		case <-f.done:
-			f.logger.Debugln("goroutine Producer lru closing")
-			return
+		case e := <-f.input:
+			leftover = append(leftover, e.item)
+		default:
+			break collect
+		}
+	}
+	if f.unbounded != nil {
+		for e, ok := f.unbounded.pop(); ok; e, ok = f.unbounded.pop() {
+			leftover = append(leftover, e.item)
 		}
 	}
+	if len(leftover) > 0 {
+		f.logger.Warnln("Producer closed with", len(leftover), "items left undrained")
+	}
+
+	return int64(f.delivered.Load() - startDelivered), int64(f.dropCount() - startDropped), leftover
 }
 
-// goroutine_Producer_all implements the all consumers fanout strategy.
-func (f *Producer[T]) goroutine_Producer_all() {
-	f.logger.Debugln("goroutine producer all started")
+// inputEmpty reports whether no written item is waiting to be dispatched.
+func (f *Producer[T]) inputEmpty() bool {
+	return len(f.input) == 0 && (f.unbounded == nil || f.unbounded.len() == 0)
+}
+
+// ShutdownOrdered shuts down the Producer in a fixed order and waits for it to complete:
+// first writes are rejected, then the dispatch goroutine is stopped, then all consumers are
+// cancelled and removed. Items still in the input buffer are discarded.
+// It returns ctx.Err() if the context is cancelled before the teardown has finished.
+func (f *Producer[T]) ShutdownOrdered(ctx context.Context) error {
+	f.Close()
+
+	select {
+	case <-f.closed:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
 	for {
+		f.consumers_mu.Lock()
+		count := len(f.consumers)
+		changed := f.consumers_changed
+		f.consumers_mu.Unlock()
+
+		if count == 0 {
+			return nil
+		}
+
 		select {
-		case item := <-f.input:
-			f.consumers_mu.Lock()
-			for _, consumer := range f.consumers {
-				select {
-				case consumer.Messages <- item:
-					consumer.lastUsed = time.Now()
-				default:
-					f.logger.Warnln("Consumer buffer is full, dropping item")
-				}
-			}
-			f.consumers_mu.Unlock()
-		case <-f.done:
-			f.logger.Debugln("goroutine Producer all closing")
-			return
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
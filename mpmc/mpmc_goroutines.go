@@ -0,0 +1,41 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrGoroutineLimit is returned by TryCreateConsumer when the package-wide goroutine limit is reached.
+var ErrGoroutineLimit = errors.New("goroutine limit reached")
+
+var (
+	// activeGoroutines counts the internal goroutines of all Producers in the process.
+	activeGoroutines atomic.Int64
+	// goroutineLimit is the soft limit enforced by TryCreateConsumer, zero means unlimited.
+	goroutineLimit atomic.Int64
+)
+
+// ActiveGoroutines returns the number of internal goroutines currently running across all
+// Producers in the process, i.e. the sum of their GoroutineCount values.
+func ActiveGoroutines() int {
+	return int(activeGoroutines.Load())
+}
+
+// SetGoroutineLimit sets a soft, package-wide limit on internal goroutines.
+// When ActiveGoroutines has reached the limit, TryCreateConsumer refuses to create consumers.
+// CreateConsumer and other APIs are not affected. Zero, the default, disables the limit.
+func SetGoroutineLimit(limit int) {
+	goroutineLimit.Store(int64(limit))
+}
+
+// TryCreateConsumer is like CreateConsumer but returns ErrGoroutineLimit instead of creating
+// a consumer when the limit set with SetGoroutineLimit has been reached. The check is not atomic
+// with the creation, so concurrent callers may overshoot the limit slightly.
+func (f *Producer[T]) TryCreateConsumer(ctx context.Context) (*Consumer[T], error) {
+	if limit := goroutineLimit.Load(); limit > 0 && activeGoroutines.Load() >= limit {
+		f.logger.Warnln("Goroutine limit of", limit, "reached, refusing to create consumer")
+		return nil, ErrGoroutineLimit
+	}
+	return f.CreateConsumer(ctx), nil
+}
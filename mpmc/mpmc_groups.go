@@ -0,0 +1,116 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sort"
+)
+
+// ErrNoGroupMembers is returned by WriteKeyed when the consumer group has no members.
+var ErrNoGroupMembers = errors.New("no members in consumer group")
+
+// DefaultHash is the default key hash of consumer groups: 64-bit FNV-1a.
+func DefaultHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// JoinGroup creates a new Consumer that is a member of the named consumer group.
+// Items written with WriteKeyed to the group are partitioned over its members by key.
+// The Consumer leaves the group when it is removed from the Producer. It also receives
+// the items delivered by the Producer's strategy like any other consumer.
+func (f *Producer[T]) JoinGroup(ctx context.Context, group string) (result *Consumer[T]) {
+	result = newConsumer(f, ctx, f.consumer_buffer_size)
+	result.group = group
+	result.groupHash = f.hash(result.id)
+	result.joinSeq = f.group_joins.Add(1)
+	f.addConsumer(result)
+	return
+}
+
+// WriteKeyed sends an item to the Producer's input channel to be delivered to the one member of
+// the consumer group that owns the key. Ownership is decided by rendezvous hashing, so all items
+// with the same key go to the same member, in order, while membership is stable, and a join or
+// leave only moves the keys of the member that joined or left; with WithHashFunc it is the hash
+// modulo the group size instead, which moves most keys. After a rebalance, items for a
+// moved key can be processed by the new owner while the old owner still holds earlier ones in
+// its buffer, so per-key order is only guaranteed once the old owner has caught up.
+// Items are not passed to the fallback strategy, which would break the per-key order; an item
+// whose owner's buffer is full is dropped.
+// It returns ErrNoGroupMembers if the group has no members at the time of the call, and an error
+// if the Producer is closed or if the buffer is full.
+func (f *Producer[T]) WriteKeyed(group, key string, item T) error {
+	f.consumers_mu.Lock()
+	members := len(f.groupMembers(group))
+	f.consumers_mu.Unlock()
+	if members == 0 {
+		f.logger.Warnln("No members in consumer group", group, "dropping item")
+		f.dropped(DropNoConsumers, item)
+		return ErrNoGroupMembers
+	}
+	return f.write(envelope[T]{item: item, group: group, key: key})
+}
+
+// groupMembers returns the attached consumers that are members of the group.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) groupMembers(group string) ConsumerList[T] {
+	result := ConsumerList[T]{}
+	for _, consumer := range f.consumers {
+		if consumer.group == group {
+			result = append(result, consumer)
+		}
+	}
+	return result
+}
+
+// select_keyed returns the group member that owns the key: the member with the highest
+// rendezvous score for the key's hash. Keys whose hashes collide always share an owner.
+// With a custom hash set by WithHashFunc it is the member at the hash modulo the group size,
+// in join order. It may reorder members.
+func (f *Producer[T]) select_keyed(members ConsumerList[T], key string) *Consumer[T] {
+	keyHash := f.hash(key)
+	if f.hash_modulo {
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].joinSeq < members[j].joinSeq
+		})
+		return members[keyHash%uint64(len(members))]
+	}
+
+	var selected *Consumer[T]
+	var selectedScore uint64
+	for _, member := range members {
+		score := mix64(keyHash ^ member.groupHash)
+		if selected == nil || score > selectedScore || (score == selectedScore && member.id < selected.id) {
+			selected = member
+			selectedScore = score
+		}
+	}
+	return selected
+}
+
+// rebalanced reports the current members of a consumer group to the rebalance callback.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) rebalanced(group string) {
+	if group == "" || f.on_rebalance == nil {
+		return
+	}
+	members := f.groupMembers(group)
+	ids := make([]string, len(members))
+	for i, member := range members {
+		ids[i] = member.id
+	}
+	sort.Strings(ids)
+	f.on_rebalance(group, ids)
+}
+
+// mix64 is the splitmix64 finalizer, which spreads the bits of a combined hash.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
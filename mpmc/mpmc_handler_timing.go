@@ -0,0 +1,36 @@
+package mpmc
+
+import "time"
+
+// handleTimed calls handle with item for one of the Consumer's Run helpers and records how long
+// it took, unless handler timing was disabled with WithoutHandlerTiming.
+func handleTimed[T, I any](c *Consumer[T], handle func(I) error, item I) error {
+	if c.owner.handler_timing_off {
+		return handle(item)
+	}
+	start := time.Now()
+	err := handle(item)
+	c.recordHandler(time.Since(start))
+	return err
+}
+
+// recordHandler adds one handler call of the given duration to the Consumer's handler stats.
+func (c *Consumer[T]) recordHandler(d time.Duration) {
+	c.handled.Add(1)
+	c.handleSum.Add(int64(d))
+	for {
+		longest := c.handleMax.Load()
+		if int64(d) <= longest || c.handleMax.CompareAndSwap(longest, int64(d)) {
+			return
+		}
+	}
+}
+
+// handlerStats returns the number of timed handler calls and their average and maximum duration.
+func (c *Consumer[T]) handlerStats() (calls uint64, avg, longest time.Duration) {
+	calls = c.handled.Load()
+	if calls > 0 {
+		avg = time.Duration(c.handleSum.Load() / int64(calls))
+	}
+	return calls, avg, time.Duration(c.handleMax.Load())
+}
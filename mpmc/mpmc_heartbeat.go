@@ -0,0 +1,32 @@
+package mpmc
+
+import "time"
+
+// goroutine_Producer_heartbeat sends a heartbeat item to every consumer that has not received
+// a real item within the heartbeat interval.
+func (f *Producer[T]) goroutine_Producer_heartbeat() {
+	f.logger.Debugln("goroutine producer heartbeat started")
+	ticker := time.NewTicker(f.heartbeat_interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			f.consumers_mu.Lock()
+			for _, consumer := range f.consumers {
+				if now.Sub(consumer.lastUsed) < f.heartbeat_interval {
+					continue
+				}
+				// Heartbeats are best effort and are not counted as deliveries,
+				// so they neither update lastUsed nor the consumer's stats, and never evict an item
+				if !consumer.offerIfRoom(f.heartbeat()) {
+					f.logger.Debugln("Consumer", consumer.id, "buffer is full, skipping heartbeat")
+				}
+			}
+			f.consumers_mu.Unlock()
+		case <-f.done:
+			f.logger.Debugln("goroutine Producer heartbeat closing")
+			return
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package mpmc
+
+import "time"
+
+// goroutine_Producer_idle closes the Producer once no write has been made for the idle timeout.
+func (f *Producer[T]) goroutine_Producer_idle() {
+	f.logger.Debugln("goroutine producer idle timer started")
+	timer := time.NewTimer(f.idle_timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case now := <-timer.C:
+			// Writes do not reset the timer, so check how long ago the last one was
+			idle := now.Sub(time.Unix(0, f.last_write.Load()))
+			if idle < f.idle_timeout {
+				timer.Reset(f.idle_timeout - idle)
+				continue
+			}
+			f.logger.Infoln("No writes for", f.idle_timeout, "closing Producer")
+			f.Close()
+			if f.on_idle_close != nil {
+				f.on_idle_close()
+			}
+			return
+		case <-f.done:
+			f.logger.Debugln("goroutine Producer idle timer closing")
+			return
+		}
+	}
+}
@@ -0,0 +1,34 @@
+//go:build go1.23
+
+package mpmc
+
+import (
+	"context"
+	"iter"
+)
+
+// All returns an iterator over the items received from Messages, for use with range:
+//
+//	for item := range consumer.All(ctx) {
+//		...
+//	}
+//
+// The iteration ends when ctx is cancelled or the Consumer is closed, and when the loop body
+// breaks out. Items still buffered when the Consumer is closed are left in Messages; use
+// DrainBuffered or OnLeftover to handle them.
+func (c *Consumer[T]) All(ctx context.Context) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			select {
+			case item := <-c.Messages:
+				if !yield(item) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-c.ctx.Done():
+				return
+			}
+		}
+	}
+}
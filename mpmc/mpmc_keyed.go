@@ -0,0 +1,130 @@
+package mpmc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultKeyIdleTimeout is how long a KeyedConsumer worker with nothing to do is kept when no
+// positive idle duration is given.
+const DefaultKeyIdleTimeout = time.Minute
+
+// keyWorker is the goroutine that processes the items of one key in order.
+// Only the dispatching goroutine sends to items and closes it.
+type keyWorker[T any] struct {
+	items    chan T
+	pending  atomic.Int64
+	lastUsed atomic.Int64
+}
+
+// KeyedConsumer creates a Consumer that routes each item it receives by key to a dedicated
+// worker goroutine, so that items with the same key are handled serially and in order while
+// different keys are handled concurrently. A worker is started the first time its key is seen
+// and stopped once it has had nothing to do for the idle duration.
+// At most maxKeys workers are live at a time: when a new key arrives at the limit, the least
+// recently used worker with nothing to do is stopped early, or, if every worker is busy, the
+// item waits until one finishes, which holds back the Consumer's other items meanwhile.
+// A worker's own backlog is bounded by the consumer buffer size in the same way.
+// When ctx is cancelled or the Consumer is closed, workers finish the items already routed to
+// them and stop. Items are delivered to the Consumer by the Producer's strategy like any other.
+func (f *Producer[T]) KeyedConsumer(ctx context.Context, key func(T) string, maxKeys int, idle time.Duration, handle func(T)) (result *Consumer[T]) {
+	if maxKeys < 1 {
+		maxKeys = 1
+	}
+	if idle <= 0 {
+		idle = DefaultKeyIdleTimeout
+	}
+	result = f.CreateConsumer(ctx)
+	f.startGoroutine(func() {
+		f.routeKeys(result, key, maxKeys, idle, handle)
+	})
+	return
+}
+
+// routeKeys reads the Consumer's items and routes them to per-key workers until it closes.
+func (f *Producer[T]) routeKeys(c *Consumer[T], key func(T) string, maxKeys int, idle time.Duration, handle func(T)) {
+	workers := make(map[string]*keyWorker[T])
+	freed := make(chan struct{}, 1)
+	defer func() {
+		for _, worker := range workers {
+			close(worker.items)
+		}
+	}()
+
+	stop := func(k string) {
+		close(workers[k].items)
+		delete(workers, k)
+		f.logger.Debugln("Keyed worker", k, "stopped")
+	}
+
+	// evict stops the least recently used worker with nothing to do and reports whether there was one
+	evict := func() bool {
+		// The empty string is a valid key, so the candidate is tracked by its worker
+		var oldest *keyWorker[T]
+		var oldestKey string
+		for k, worker := range workers {
+			if worker.pending.Load() == 0 && (oldest == nil || worker.lastUsed.Load() < oldest.lastUsed.Load()) {
+				oldest, oldestKey = worker, k
+			}
+		}
+		if oldest == nil {
+			return false
+		}
+		stop(oldestKey)
+		return true
+	}
+
+	ticker := time.NewTicker(idle)
+	defer ticker.Stop()
+
+	for {
+		var item T
+		select {
+		case item = <-c.Messages:
+		case now := <-ticker.C:
+			for k, worker := range workers {
+				if worker.pending.Load() == 0 && now.Sub(time.Unix(0, worker.lastUsed.Load())) >= idle {
+					stop(k)
+				}
+			}
+			continue
+		case <-c.ctx.Done():
+			return
+		}
+
+		k := key(item)
+		worker, ok := workers[k]
+		if !ok {
+			for len(workers) >= maxKeys && !evict() {
+				select {
+				case <-freed:
+				case <-c.ctx.Done():
+					return
+				}
+			}
+			worker = &keyWorker[T]{items: make(chan T, f.consumer_buffer_size)}
+			workers[k] = worker
+			f.startGoroutine(func() {
+				for item := range worker.items {
+					handle(item)
+					worker.lastUsed.Store(time.Now().UnixNano())
+					worker.pending.Add(-1)
+					select {
+					case freed <- struct{}{}:
+					default:
+					}
+				}
+			})
+			f.logger.Debugln("Keyed worker", k, "started")
+		}
+
+		worker.pending.Add(1)
+		worker.lastUsed.Store(time.Now().UnixNano())
+		select {
+		case worker.items <- item:
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package mpmc
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyQuantiles are the quantiles reported by LatencyQuantiles when WithLatencyQuantiles
+// is given none.
+var DefaultLatencyQuantiles = []float64{0.5, 0.95, 0.99}
+
+const (
+	// latencyLinearBits is the number of bits of the nanosecond values counted exactly, one per bucket.
+	latencyLinearBits = 5
+	latencyLinear     = 1 << latencyLinearBits
+	// latencySubBuckets is the number of buckets per power of two above the linear range, which
+	// bounds the relative error of a reported quantile to 1/latencySubBuckets.
+	latencySubBuckets = latencyLinear / 2
+	// latencyBuckets covers every non-negative int64 nanosecond value.
+	latencyBuckets = latencyLinear + (63-latencyLinearBits)*latencySubBuckets
+)
+
+// latencyHistogram is a fixed-size log-linear histogram of durations, updated without locks.
+type latencyHistogram struct {
+	quantiles []float64
+	counts    [latencyBuckets]atomic.Uint64
+}
+
+// record counts one duration.
+func (h *latencyHistogram) record(latency time.Duration) {
+	h.counts[latencyBucket(latency)].Add(1)
+}
+
+// latencyBucket returns the index of the bucket that counts the given duration.
+func latencyBucket(latency time.Duration) int {
+	if latency < latencyLinear {
+		return int(max(latency, 0))
+	}
+	shift := bits.Len64(uint64(latency)) - latencyLinearBits
+	mantissa := int(uint64(latency) >> shift)
+	return latencyLinear + (shift-1)*latencySubBuckets + mantissa - latencySubBuckets
+}
+
+// latencyBucketMax returns the largest duration counted by the bucket with the given index.
+func latencyBucketMax(index int) time.Duration {
+	if index < latencyLinear {
+		return time.Duration(index)
+	}
+	shift := (index-latencyLinear)/latencySubBuckets + 1
+	mantissa := uint64((index-latencyLinear)%latencySubBuckets + latencySubBuckets)
+	upper := (mantissa+1)<<shift - 1
+	if upper > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(upper)
+}
+
+// LatencyQuantiles returns the quantiles of the time delivered items spent between being written
+// and being delivered to their first consumer, keyed by the quantiles set with WithLatencyQuantiles.
+// Each value is the upper bound of a histogram bucket, so it overestimates the true quantile by at
+// most 1/16th. It returns nil if WithLatencyQuantiles is not set and an empty map before the first
+// delivery. The histogram covers the whole life of the Producer and is not reset by StatsAndReset.
+func (f *Producer[T]) LatencyQuantiles() map[float64]time.Duration {
+	h := f.latency_histogram
+	if h == nil {
+		return nil
+	}
+
+	var counts [latencyBuckets]uint64
+	var total uint64
+	for i := range counts {
+		counts[i] = h.counts[i].Load()
+		total += counts[i]
+	}
+
+	result := make(map[float64]time.Duration, len(h.quantiles))
+	if total == 0 {
+		return result
+	}
+	for _, q := range h.quantiles {
+		rank := uint64(math.Ceil(q * float64(total)))
+		rank = min(max(rank, 1), total)
+		var seen uint64
+		for i, count := range counts {
+			seen += count
+			if seen >= rank {
+				result[q] = latencyBucketMax(i)
+				break
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,29 @@
+package mpmc
+
+// OnLeftover sets a callback that receives the items still buffered when the Consumer is
+// removed from its Producer, instead of leaving them in Messages. The callback runs once per
+// item, one at a time, on the goroutine that removes the Consumer, after the Producer has stopped
+// delivering to it, so it sees every item that was not read. Items are passed oldest first,
+// except that priority buffers set with WithPriorityConsumerBuffers pass them highest priority
+// first. Items read from Messages concurrently are not passed to the callback. The callback must
+// not block for long: while it runs, Close of the Producer waits if the Producer uses
+// WithManualConsumerRemoval. Calling OnLeftover again replaces the callback; nil removes it.
+func (c *Consumer[T]) OnLeftover(handle func(T)) {
+	if handle == nil {
+		c.leftover.Store(nil)
+		return
+	}
+	c.leftover.Store(&handle)
+}
+
+// flushLeftovers passes the items still buffered to the callback set with OnLeftover, once.
+// It must be called without consumers_mu held, after the Consumer was removed.
+func (c *Consumer[T]) flushLeftovers() {
+	handle := c.leftover.Load()
+	if handle == nil {
+		return
+	}
+	c.flushOnce.Do(func() {
+		c.eachBuffered(*handle)
+	})
+}
@@ -0,0 +1,29 @@
+package mpmc
+
+import "time"
+
+// lockDispatch locks consumers_mu for dispatching an item, noting when the lock was acquired
+// if lock timing is enabled with WithLockTiming.
+func (f *Producer[T]) lockDispatch() {
+	f.consumers_mu.Lock()
+	if f.lock_timing {
+		f.lock_acquired = time.Now()
+	}
+}
+
+// unlockDispatch unlocks consumers_mu after dispatching an item, recording how long it was held
+// if lock timing is enabled with WithLockTiming.
+func (f *Producer[T]) unlockDispatch() {
+	if f.lock_timing {
+		held := int64(time.Since(f.lock_acquired))
+		f.lock_total.Add(held)
+		f.lock_count.Add(1)
+		for {
+			longest := f.lock_max.Load()
+			if held <= longest || f.lock_max.CompareAndSwap(longest, held) {
+				break
+			}
+		}
+	}
+	f.consumers_mu.Unlock()
+}
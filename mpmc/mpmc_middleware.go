@@ -0,0 +1,78 @@
+package mpmc
+
+import (
+	"slices"
+	"sync"
+)
+
+// Use appends a middleware to the chain applied to each item after the input filter and before
+// the item is delivered, on the dispatch goroutine and on the goroutine calling WriteAllSync.
+// A middleware receives the next step of the chain and returns the step that replaces it: it can
+// observe the item, pass a transformed item to next, or drop the item by not calling next.
+// Middleware added first runs first, so validation is usually added before enrichment. Dropped
+// items are counted in ProducerStats.Filtered like items discarded by the input filter, not as drops.
+// next must be called synchronously and at most once; calling it again replaces the item.
+// The chain is built more than once so that concurrent writes each run their own copy, so mw
+// may be called several times and the steps it returns must be reentrant and safe for
+// concurrent use. During dispatch the chain runs while the Producer's consumers are locked,
+// so middleware must not call back into the Producer, e.g. ConsumerInfo would deadlock.
+// Every item pays one function call per middleware and nothing else, so middleware should be
+// as fast as the rest of dispatch: slow middleware delays every consumer.
+// Use can be called at any time; items already being dispatched are not affected.
+func (f *Producer[T]) Use(mw func(next func(T)) func(T)) {
+	f.middleware_mu.Lock()
+	defer f.middleware_mu.Unlock()
+
+	f.middleware = append(f.middleware, mw)
+	f.chain.Store(&middlewareChain[T]{middleware: slices.Clone(f.middleware)})
+}
+
+// middlewareChain is the middleware added with Use up to some point. The composed chains are
+// pooled, so that each intercept runs its own without composing one per item.
+type middlewareChain[T any] struct {
+	middleware []func(next func(T)) func(T)
+	runs       sync.Pool
+}
+
+// chainRun is one composed middleware chain, whose last step keeps the item for dispatch.
+type chainRun[T any] struct {
+	run    func(T)
+	item   T
+	passed bool
+}
+
+// get returns a composed chain that no other caller is running.
+func (c *middlewareChain[T]) get() *chainRun[T] {
+	if r, ok := c.runs.Get().(*chainRun[T]); ok {
+		return r
+	}
+	r := &chainRun[T]{}
+	r.run = func(item T) {
+		r.item, r.passed = item, true
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		r.run = c.middleware[i](r.run)
+	}
+	return r
+}
+
+// intercept runs an item through the middleware chain, replacing it with the item passed to the
+// end of the chain. It reports false, and counts the item as filtered, if a middleware dropped it.
+func (f *Producer[T]) intercept(e *envelope[T]) bool {
+	chain := f.chain.Load()
+	if chain == nil {
+		return true
+	}
+
+	var zero T
+	r := chain.get()
+	defer chain.runs.Put(r)
+	r.passed = false
+	r.run(e.item)
+	if !r.passed {
+		f.drop_counts[DropFiltered].Add(1)
+		return false
+	}
+	e.item, r.item = r.item, zero
+	return true
+}
@@ -0,0 +1,42 @@
+package mpmc
+
+import "time"
+
+// OccupancyBuckets is the number of buckets in a Consumer's buffer occupancy histogram.
+// Bucket i counts the samples where the buffer was between i and i+1 tenths full; the last
+// bucket also counts the samples where it was completely full.
+const OccupancyBuckets = 10
+
+// occupancyBucket returns the bucket of a buffer holding length items out of capacity.
+func occupancyBucket(length, capacity int) int {
+	return min(length*OccupancyBuckets/capacity, OccupancyBuckets-1)
+}
+
+// sampleOccupancy records the current occupancy of the Consumer's buffer.
+// Consumers without a buffer are not sampled.
+func (c *Consumer[T]) sampleOccupancy() {
+	if capacity := c.bufferCap(); capacity > 0 {
+		c.occupancy[occupancyBucket(c.bufferLen(), capacity)].Add(1)
+	}
+}
+
+// goroutine_Producer_occupancy samples the buffer occupancy of every consumer at each interval.
+func (f *Producer[T]) goroutine_Producer_occupancy() {
+	f.logger.Debugln("goroutine producer occupancy sampler started")
+	ticker := time.NewTicker(f.occupancy_interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			f.consumers_mu.Lock()
+			for _, consumer := range f.consumers {
+				consumer.sampleOccupancy()
+			}
+			f.consumers_mu.Unlock()
+		case <-f.done:
+			f.logger.Debugln("goroutine Producer occupancy sampler closing")
+			return
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package mpmc
+
+import (
+	"log/slog"
+	"math"
+	"time"
+)
+
+// Option configures optional behaviour of a Producer.
+// Options are applied in order by NewProducer before the Producer starts dispatching.
+type Option[T any] func(*Producer[T])
+
+// WithFallback sets a secondary fanout strategy that is used when the primary strategy
+// could not deliver an item to any consumer, for example because the selected consumer's buffer is full.
+func WithFallback[T any](kind ProducerKind) Option[T] {
+	return func(f *Producer[T]) {
+		f.fallback = kind
+		f.has_fallback = true
+	}
+}
+
+// DefaultDispatchBatch is the default maximum number of items the All strategy dispatches per lock hold.
+const DefaultDispatchBatch = 64
+
+// WithDispatchBatch sets how many items already waiting in the input buffer the All strategy
+// dispatches while holding the consumers lock once, DefaultDispatchBatch by default.
+// Larger batches reduce lock churn under bursts at the cost of delaying consumer
+// changes until the batch is done; 1 disables batching. With four consumers, BenchmarkFanoutAll
+// measures roughly half the per-item cost with the default batch size compared to no batching.
+func WithDispatchBatch[T any](n int) Option[T] {
+	return func(f *Producer[T]) {
+		if n < 1 {
+			n = 1
+		}
+		f.dispatch_batch = n
+	}
+}
+
+// WithMaxInFlightPerCaller limits how many items written with WriteAs a single caller
+// may have waiting in the input buffer at the same time. Zero means unlimited.
+func WithMaxInFlightPerCaller[T any](max uint) Option[T] {
+	return func(f *Producer[T]) {
+		f.max_inflight = max
+	}
+}
+
+// WithLogLevel sets the minimum level of the Producer's log output, using the logger.LogLevel constants.
+func WithLogLevel[T any](level int) Option[T] {
+	return func(f *Producer[T]) {
+		f.log_level = level
+	}
+}
+
+// WithLogFlags sets the log package flags of the Producer's log output, logger.DefaultFlags by default.
+// Pass 0 to disable timestamps when the log destination already adds its own.
+func WithLogFlags[T any](flags int) Option[T] {
+	return func(f *Producer[T]) {
+		f.log_flags = flags
+	}
+}
+
+// DefaultLoadStaleAfter is how long a load reported with Consumer.ReportLoad is trusted by default.
+const DefaultLoadStaleAfter = 5 * time.Second
+
+// WithLoadStaleAfter sets how long a load reported with Consumer.ReportLoad is trusted by the
+// ReportedLoad strategy. Older reports are treated as fully loaded.
+func WithLoadStaleAfter[T any](staleAfter time.Duration) Option[T] {
+	return func(f *Producer[T]) {
+		f.load_stale_after = staleAfter
+	}
+}
+
+// WithWeightedLRUScore replaces the scoring function of the WeightedLRU strategy.
+// The consumer with the highest score receives the next item.
+func WithWeightedLRUScore[T any](score func(weight uint, idle time.Duration) float64) Option[T] {
+	return func(f *Producer[T]) {
+		f.weighted_lru_score = score
+	}
+}
+
+// WithIdleTimeout makes the Producer close itself, and with it all of its consumers, once no
+// write has been made for the given duration. Every write counts as activity, including writes
+// that are rejected because the buffer is full. Use WithOnIdleClose to be told when it happens.
+func WithIdleTimeout[T any](timeout time.Duration) Option[T] {
+	return func(f *Producer[T]) {
+		f.idle_timeout = timeout
+	}
+}
+
+// WithOnIdleClose sets a function that is called after the Producer closed itself because of
+// WithIdleTimeout, for example to drop references to it. It is not called for other closes.
+func WithOnIdleClose[T any](onIdleClose func()) Option[T] {
+	return func(f *Producer[T]) {
+		f.on_idle_close = onIdleClose
+	}
+}
+
+// WithStartSequence sets the initial sequence number, so the first dispatched item is numbered n+1.
+func WithStartSequence[T any](n int64) Option[T] {
+	return func(f *Producer[T]) {
+		f.sequence.Store(n)
+	}
+}
+
+// WithHeartbeat makes the Producer send a heartbeat item, produced by makeHeartbeat, to every
+// consumer that has not received an item for the given interval, so idle consumers wake up
+// periodically. Heartbeats are skipped for consumers whose buffer is full, and never evict an
+// item from a buffer set up with WithPriorityConsumerBuffers. They do not count as deliveries
+// in the consumer's stats and are not sent in ProducerKind_Pull mode.
+func WithHeartbeat[T any](interval time.Duration, makeHeartbeat func() T) Option[T] {
+	return func(f *Producer[T]) {
+		if interval <= 0 {
+			return
+		}
+		f.heartbeat_interval = interval
+		f.heartbeat = makeHeartbeat
+	}
+}
+
+// WithSlog makes the Producer log through the given slog.Logger instead of standard output.
+// The level set with WithLogLevel still applies and the item type is attached as the "type"
+// attribute; WithLogFlags has no effect.
+func WithSlog[T any](s *slog.Logger) Option[T] {
+	return func(f *Producer[T]) {
+		f.log_slog = s
+	}
+}
+
+// WithAdaptiveBuffer replaces the fixed consumer buffer with one that starts at min items,
+// doubles whenever it is full, up to max items, and halves again once it is less than a
+// quarter full. Items are only dropped for a consumer when its buffer is full at max.
+// The buffer is a queue feeding an unbuffered Messages channel through one extra goroutine
+// per consumer, which adds a goroutine hand-off to each item's latency and lets memory use
+// grow to max items per consumer during bursts.
+func WithAdaptiveBuffer[T any](min, max uint) Option[T] {
+	return func(f *Producer[T]) {
+		if max < min {
+			max = min
+		}
+		f.adaptive_min = min
+		f.adaptive_max = max
+	}
+}
+
+// WithPriorityConsumerBuffers replaces each consumer's buffer with one that releases items in
+// order of the given priority, highest first, and oldest first among items of equal priority.
+// When a consumer's buffer is full, an item evicts the lowest-priority buffered item, the most
+// recent one among equals, if that item has a strictly lower priority; the evicted item is
+// counted as a DropConsumerFull drop. Otherwise the new item is not delivered, as usual.
+// Items are only reordered while they wait in the buffer: the buffer feeds an unbuffered
+// Messages channel through one extra goroutine per consumer, which holds the next item until a
+// reader takes it, so that item can no longer be overtaken or evicted. It takes precedence over
+// WithAdaptiveBuffer.
+func WithPriorityConsumerBuffers[T any](priority func(T) int) Option[T] {
+	return func(f *Producer[T]) {
+		f.item_priority = priority
+	}
+}
+
+// WithCorrelation enables Call by telling the Producer how to store a correlation ID in an item.
+// The function returns the item with the ID set; consumers read it back to Respond.
+func WithCorrelation[T any](correlate func(item T, correlationID string) T) Option[T] {
+	return func(f *Producer[T]) {
+		f.correlate = correlate
+	}
+}
+
+// WithManualConsumerRemoval stops the Producer from removing consumers when their context is done,
+// which also saves the watcher goroutine per consumer. Consumers then stay attached until
+// RemoveConsumer is called or the Producer is closed; dispatch skips consumers whose context is done.
+func WithManualConsumerRemoval[T any]() Option[T] {
+	return func(f *Producer[T]) {
+		f.manual_removal = true
+	}
+}
+
+// WithFailOnDrop puts the Producer into a failed state the first time it drops an item, whether
+// because the input buffer is full, no consumer is available or a consumer's buffer is full.
+// From then on writes return the sticky error reported by Err, which wraps ErrDropped,
+// until Reset is called. Items already in the input buffer are still dispatched.
+func WithFailOnDrop[T any]() Option[T] {
+	return func(f *Producer[T]) {
+		f.fail_on_drop = true
+	}
+}
+
+// WithInputFilter makes the Producer discard items for which keep returns false, before any
+// fanout and without using consumer buffer space. It runs on the dispatch goroutine, so it
+// should be fast. Discarded items are counted in ProducerStats.Filtered, separately from drops.
+func WithInputFilter[T any](keep func(T) bool) Option[T] {
+	return func(f *Producer[T]) {
+		f.input_filter = keep
+	}
+}
+
+// WithEventBuffer sets how many events Producer.Events buffers, DefaultEventBuffer by default.
+func WithEventBuffer[T any](size uint) Option[T] {
+	return func(f *Producer[T]) {
+		f.events_buffer = size
+	}
+}
+
+// WithBlockingEvents makes the Producer wait for room in the event stream instead of discarding
+// events when it is full. Events are sent from the dispatch path, often while consumers are
+// locked, so the stream must be read continuously and its reader must not call back into the
+// Producer, or dispatch stalls.
+func WithBlockingEvents[T any]() Option[T] {
+	return func(f *Producer[T]) {
+		f.events_block = true
+	}
+}
+
+// DefaultRetryMinBackoff and DefaultRetryMaxBackoff bound the backoff of the retry queue
+// when WithRetryQueue is given zero durations.
+const (
+	DefaultRetryMinBackoff = time.Millisecond
+	DefaultRetryMaxBackoff = 100 * time.Millisecond
+)
+
+// WithRetryQueue gives WriteRetry a queue of up to size items to park writes that find the input
+// buffer full. Parked items are retried after minBackoff, doubling up to maxBackoff while the
+// input buffer stays full. It starts one extra goroutine.
+func WithRetryQueue[T any](size uint, minBackoff, maxBackoff time.Duration) Option[T] {
+	return func(f *Producer[T]) {
+		if minBackoff <= 0 {
+			minBackoff = DefaultRetryMinBackoff
+		}
+		if maxBackoff <= 0 {
+			maxBackoff = DefaultRetryMaxBackoff
+		}
+		if maxBackoff < minBackoff {
+			maxBackoff = minBackoff
+		}
+		f.retry_size = size
+		f.retry_min_backoff = minBackoff
+		f.retry_max_backoff = maxBackoff
+	}
+}
+
+// WithUnboundedInput puts a growable queue in front of the input buffer, so writes never return
+// ErrBufferFull and WriteWhenReady never blocks on a full buffer. The queue grows as long as
+// writers outpace dispatch, for example while the Producer is paused, so memory use is only
+// bounded by what the writers produce; use it only where that is controlled elsewhere.
+// Items pass through one extra goroutine on their way to the input buffer.
+func WithUnboundedInput[T any]() Option[T] {
+	return func(f *Producer[T]) {
+		f.unbounded = newAdaptiveQueue[envelope[T]](uint(cap(f.input)), math.MaxInt)
+	}
+}
+
+// WithHashFunc replaces DefaultHash as the key hash of consumer groups, for example to partition
+// keys the same way as another system. With a custom hash the owner of a key is the member at
+// index hash(key) % n of the group's n members, in the order they joined the group, instead of
+// the rendezvous owner, so partition i belongs to the i-th member to join. Unlike rendezvous
+// hashing, a join or leave then moves most keys to another member.
+func WithHashFunc[T any](hash func(key string) uint64) Option[T] {
+	return func(f *Producer[T]) {
+		f.hash = hash
+		f.hash_modulo = true
+	}
+}
+
+// WithOnRebalance sets a function that is called with the sorted member IDs of a consumer group
+// every time a member joins or leaves it. It is called while the Producer's consumers are locked,
+// so it must be quick and must not call back into the Producer.
+func WithOnRebalance[T any](onRebalance func(group string, members []string)) Option[T] {
+	return func(f *Producer[T]) {
+		f.on_rebalance = onRebalance
+	}
+}
+
+// WithDispatchTracer traces the dispatch of items written with WriteTraced.
+func WithDispatchTracer[T any](tracer DispatchTracer) Option[T] {
+	return func(f *Producer[T]) {
+		f.tracer = tracer
+	}
+}
+
+// WithIDGenerator sets the function that generates consumer IDs instead of random UUID-like IDs,
+// for example to get stable IDs in tests. It is called while creating each consumer and must
+// return unique IDs.
+func WithIDGenerator[T any](generate func() string) Option[T] {
+	return func(f *Producer[T]) {
+		f.id_generator = generate
+	}
+}
+
+// WithLatencyQuantiles records the time-in-queue of delivered items in a histogram so that
+// LatencyQuantiles can report the given quantiles, DefaultLatencyQuantiles if none are given.
+// Quantiles are between 0 and 1, for example 0.99 for the 99th percentile.
+func WithLatencyQuantiles[T any](quantiles ...float64) Option[T] {
+	return func(f *Producer[T]) {
+		if len(quantiles) == 0 {
+			quantiles = DefaultLatencyQuantiles
+		}
+		f.latency_histogram = &latencyHistogram{quantiles: quantiles}
+	}
+}
+
+// WithOccupancySampling samples the buffer occupancy of every consumer at the given interval
+// into a histogram reported in ConsumerStats.Occupancy, which shows whether consumers hover
+// near full and risk drops or are mostly empty and oversized. The sampler stops on Close.
+func WithOccupancySampling[T any](interval time.Duration) Option[T] {
+	return func(f *Producer[T]) {
+		f.occupancy_interval = interval
+	}
+}
+
+// WithFairnessWindow bounds how unevenly the Single strategy can distribute items in the short
+// term: in any window of the given number of consecutive deliveries, no consumer receives more
+// than the window divided by the number of consumers, rounded up. The consumer is still picked
+// at random among those below that share, so the order stays unpredictable. The bound applies
+// to the consumers attached at each delivery; items dropped because the picked consumer was full
+// are not counted. A window of 1 or less has no effect.
+func WithFairnessWindow[T any](window int) Option[T] {
+	return func(f *Producer[T]) {
+		f.fairness = nil
+		if window > 1 {
+			f.fairness = newFairnessWindow(window)
+		}
+	}
+}
+
+// WithInputWatermarks signals backpressure upstream: onHigh is called when the number of items
+// waiting in the input buffer reaches high, and onLow when it then falls back to low, so that
+// writers can be paused before items are dropped and resumed once the Producer has caught up.
+// Each callback is only called on a crossing, alternately, first onHigh. The occupancy is checked
+// after every write and after every item dispatched, and the callbacks run on the goroutine that
+// noticed the crossing, a writer or the dispatch goroutine, so they must be quick and must not
+// write to the Producer. A high watermark of 0 or less disables the callbacks, and low is capped
+// below high.
+func WithInputWatermarks[T any](low, high int, onHigh, onLow func()) Option[T] {
+	return func(f *Producer[T]) {
+		f.watermark_low = min(low, high-1)
+		f.watermark_high = max(high, 0)
+		f.on_high = onHigh
+		f.on_low = onLow
+	}
+}
+
+// WithReplay keeps the last size items dispatched, whether or not they were delivered, and
+// preloads them into the buffer of every new durable consumer, oldest first, so that it starts
+// caught up. A consumer whose buffer is smaller than the backlog only receives the most recent
+// items that fit, so the consumer buffer size bounds the replay as much as size does. Consumers
+// created with CreateConsumerEphemeral start from the next item instead. Replay does not apply
+// to ProducerKind_Pull.
+func WithReplay[T any](size uint) Option[T] {
+	return func(f *Producer[T]) {
+		f.replay = make([]T, size)
+	}
+}
+
+// WithStartupBuffer holds up to n items written before any consumer was added, instead of
+// dropping them, and distributes them with the Producer's strategy as soon as the first consumer
+// is added. Unlike WithReplay the buffer drains once: after the first consumer was added nothing
+// is held anymore, even if all consumers are removed later. Items past n, and items the first
+// consumers cannot take, are dropped as usual. Writers waiting for delivery, as with WriteTracked
+// and WriteToLRU, are told that a held item was not delivered. WriteAllSync hands items to the
+// consumers directly, so its items are never held. The startup buffer does not apply to
+// ProducerKind_Pull.
+func WithStartupBuffer[T any](n int) Option[T] {
+	return func(f *Producer[T]) {
+		if n > 0 {
+			f.startup = make([]envelope[T], 0, n)
+		}
+	}
+}
+
+// WithoutHandlerTiming stops RunPool, RunBulk and RunWorkers from timing each handler call,
+// saving two clock reads per call. ConsumerStats then reports no handler calls or durations.
+func WithoutHandlerTiming[T any]() Option[T] {
+	return func(f *Producer[T]) {
+		f.handler_timing_off = true
+	}
+}
+
+// WithValidator checks every written item with validate on the writer's goroutine before it
+// enters the input buffer. An item for which validate returns an error is not enqueued: the
+// write returns that error and the item is counted as DropInvalid. validate must be safe for
+// concurrent use, since writers call it concurrently.
+func WithValidator[T any](validate func(T) error) Option[T] {
+	return func(f *Producer[T]) {
+		f.validator = validate
+	}
+}
+
+// WithStatsLogging logs the summary returned by StatsString at INFO level at each interval,
+// for monitoring through the logs alone. The logging goroutine stops when the Producer closes.
+func WithStatsLogging[T any](interval time.Duration) Option[T] {
+	return func(f *Producer[T]) {
+		f.stats_interval = interval
+	}
+}
+
+// WithLockTiming measures how long the dispatch goroutine holds the consumer list lock while
+// dispatching, reported as LockHolds, LockHoldAvg and LockHoldMax in ProducerStats. Creating
+// and removing consumers waits for that lock, so long hold times point at slow strategies or
+// hooks. It costs two clock reads per dispatch and is off by default.
+func WithLockTiming[T any]() Option[T] {
+	return func(f *Producer[T]) {
+		f.lock_timing = true
+	}
+}
+
+// WithOrderCheck numbers the items offered to each consumer and calls check whenever a consumer
+// accepts an item that does not directly follow the last one it accepted, with the number that
+// was expected and the one it got. A consumer's buffer keeps items in order, so in practice this
+// reports gaps: items offered to the consumer but dropped because its buffer was full, which
+// under the All strategy is every item it missed. Items are numbered from 1 for each consumer.
+// Priority buffers reorder items on purpose and items handed over by WriteAllSync are not
+// numbered. check is called on the dispatch goroutine while the consumers are locked, so it must
+// be quick and must not call back into the Producer. It adds a little work to every delivery.
+func WithOrderCheck[T any](check func(consumerID string, expected, got int64)) Option[T] {
+	return func(f *Producer[T]) {
+		f.order_check = check
+	}
+}
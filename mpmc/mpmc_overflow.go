@@ -0,0 +1,36 @@
+package mpmc
+
+// SetOverflowConsumer makes a Consumer created by this Producer its overflow consumer: it is
+// taken out of normal selection and instead receives, best-effort, every item that would
+// otherwise be dropped, whatever the reason, so that all loss can be observed in one place.
+// Items discarded by the input filter are not drops and are not sent to it.
+// Under the All strategy an item is sent once for each consumer that missed it.
+// An item caught by the overflow consumer is counted in ProducerStats.Overflowed instead of
+// DropCounts; if the overflow consumer's buffer is full too, the item is dropped and counted
+// as usual. The overflow consumer is closed with the Producer. Passing nil removes the overflow
+// consumer without closing it or returning it to normal selection.
+func (f *Producer[T]) SetOverflowConsumer(c *Consumer[T]) {
+	if c != nil && c.owner != f {
+		f.logger.Warnln("Consumer", c.id, "belongs to another Producer, not using it for overflow")
+		return
+	}
+
+	f.consumers_mu.Lock()
+	defer f.consumers_mu.Unlock()
+	if c != nil {
+		f.removeConsumer(c)
+		f.logger.Debugln("Consumer", c.id, "set as overflow consumer")
+	}
+	f.overflow.Store(c)
+}
+
+// overflowed hands a dropped item to the overflow consumer and reports whether it accepted it.
+func (f *Producer[T]) overflowed(item T) bool {
+	overflow := f.overflow.Load()
+	if overflow == nil || overflow.ctx.Err() != nil || !overflow.offer(item) {
+		return false
+	}
+	overflow.received.Add(1)
+	f.overflow_count.Add(1)
+	return true
+}
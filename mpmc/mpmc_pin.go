@@ -0,0 +1,54 @@
+package mpmc
+
+// PinTo sends every dispatched item to the consumer with the given ID only, regardless of the
+// Producer's strategy, routes, shards, groups and replication, until Unpin is called. It is meant
+// for isolating one consumer under real traffic, e.g. for debugging or as a canary. While pinned,
+// items are dropped for DropConsumerFull when the consumer's buffer is full and for
+// DropNoConsumers once the consumer is closed or removed, until Unpin is called. It returns
+// ErrUnknownConsumer if no attached consumer has that ID, in which case the pin is unchanged.
+// Pinning does not apply to ProducerKind_Pull.
+func (f *Producer[T]) PinTo(id string) error {
+	f.consumers_mu.Lock()
+	defer f.consumers_mu.Unlock()
+
+	consumer := f.findConsumer(id)
+	if consumer == nil {
+		return ErrUnknownConsumer
+	}
+	f.pinned = consumer
+	f.logger.Infoln("Dispatch pinned to consumer", id)
+	return nil
+}
+
+// Unpin ends PinTo, so items are dispatched with the Producer's strategy again.
+func (f *Producer[T]) Unpin() {
+	f.consumers_mu.Lock()
+	defer f.consumers_mu.Unlock()
+
+	if f.pinned != nil {
+		f.logger.Infoln("Dispatch unpinned from consumer", f.pinned.id)
+		f.pinned = nil
+	}
+}
+
+// distributePinned delivers an item to the consumer set with PinTo.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) distributePinned(e envelope[T]) dispatchResult {
+	target := f.pinned
+	if f.pinnedGone() {
+		f.logger.Warnln("Pinned consumer", target.id, "is gone, dropping item")
+		f.dropped(DropNoConsumers, e.item)
+		return dispatchResult{}
+	}
+	if f.sendTo(target, e.item) == nil {
+		f.dropped(DropConsumerFull, e.item)
+		return dispatchResult{}
+	}
+	return dispatchResult{consumerID: target.id, delivered: 1}
+}
+
+// pinnedGone reports whether the consumer set with PinTo is closed or no longer attached.
+// It must be called with consumers_mu held while dispatch is pinned.
+func (f *Producer[T]) pinnedGone() bool {
+	return f.pinned.ctx.Err() != nil || f.findConsumer(f.pinned.id) != f.pinned
+}
@@ -0,0 +1,153 @@
+package mpmc
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// prioritized is an item buffered in a priorityQueue with its priority and arrival order.
+type prioritized[T any] struct {
+	item     T
+	priority int
+	seq      uint64
+}
+
+// priorityHeap is a max-heap of buffered items, highest priority first and oldest first among
+// items of equal priority. It implements heap.Interface.
+type priorityHeap[T any] []prioritized[T]
+
+func (h priorityHeap[T]) Len() int { return len(h) }
+func (h priorityHeap[T]) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h priorityHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *priorityHeap[T]) Push(x any)   { *h = append(*h, x.(prioritized[T])) }
+func (h *priorityHeap[T]) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	old[len(old)-1] = prioritized[T]{}
+	*h = old[:len(old)-1]
+	return last
+}
+
+// priorityQueue is a bounded buffer that releases its highest-priority item first. When it is
+// full, a new item evicts the lowest-priority buffered item if that item has a strictly lower
+// priority. It sits between dispatch and a Consumer's Messages channel, like adaptiveQueue.
+type priorityQueue[T any] struct {
+	mu       sync.Mutex
+	items    priorityHeap[T]
+	size     int
+	seq      uint64
+	priority func(T) int
+	ready    chan struct{}
+}
+
+// newPriorityQueue creates a new priorityQueue holding at most size items, ranked by priority.
+func newPriorityQueue[T any](size uint, priority func(T) int) *priorityQueue[T] {
+	if size < 1 {
+		size = 1
+	}
+	return &priorityQueue[T]{
+		items:    make(priorityHeap[T], 0, size),
+		size:     int(size),
+		priority: priority,
+		ready:    make(chan struct{}, 1),
+	}
+}
+
+// push adds an item, evicting the lowest-priority buffered item if the queue is full and that
+// item has a lower priority; among items of the lowest priority the most recent one is evicted.
+// It reports whether the item was added, and returns the item evicted to make room, if any.
+func (q *priorityQueue[T]) push(item T) (added bool, evicted T, ok bool) {
+	entry := prioritized[T]{item: item, priority: q.priority(item)}
+
+	q.mu.Lock()
+	if len(q.items) == q.size {
+		// The lowest priority is in a leaf, but leaves are unordered, so they are all scanned
+		lowest := len(q.items) / 2
+		for i := lowest + 1; i < len(q.items); i++ {
+			if q.items[i].priority < q.items[lowest].priority ||
+				(q.items[i].priority == q.items[lowest].priority && q.items[i].seq > q.items[lowest].seq) {
+				lowest = i
+			}
+		}
+		if q.items[lowest].priority >= entry.priority {
+			q.mu.Unlock()
+			return false, evicted, false
+		}
+		evicted, ok = heap.Remove(&q.items, lowest).(prioritized[T]).item, true
+	}
+	entry.seq = q.seq
+	q.seq++
+	heap.Push(&q.items, entry)
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return true, evicted, ok
+}
+
+// pushIfRoom adds an item only if the queue is not full, so it never evicts a buffered item.
+// It reports whether the item was added.
+func (q *priorityQueue[T]) pushIfRoom(item T) bool {
+	entry := prioritized[T]{item: item, priority: q.priority(item)}
+
+	q.mu.Lock()
+	if len(q.items) == q.size {
+		q.mu.Unlock()
+		return false
+	}
+	entry.seq = q.seq
+	q.seq++
+	heap.Push(&q.items, entry)
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// pop removes the highest-priority item. It reports false if the queue is empty.
+func (q *priorityQueue[T]) pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return item, false
+	}
+	return heap.Pop(&q.items).(prioritized[T]).item, true
+}
+
+// len returns the number of queued items.
+func (q *priorityQueue[T]) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// pump moves queued items into the output channel, highest priority first, until done is closed.
+func (q *priorityQueue[T]) pump(done <-chan struct{}, output chan<- T) {
+	for {
+		item, ok := q.pop()
+		if !ok {
+			select {
+			case <-q.ready:
+				continue
+			case <-done:
+				return
+			}
+		}
+
+		select {
+		case output <- item:
+		case <-done:
+			return
+		}
+	}
+}
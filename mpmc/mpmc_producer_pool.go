@@ -0,0 +1,159 @@
+package mpmc
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ProducerPool spreads writes over several independent Producers, so that callers see a single
+// Write. It applies a fanout strategy at the Producer level, and supports only these kinds:
+// ProducerKind_Single writes each item to a random Producer, ProducerKind_All writes it to every
+// Producer, ProducerKind_LRU writes it to the least recently used Producer, and
+// ProducerKind_ReportedLoad writes it to the Producer with the fewest items waiting in its input
+// buffer, which stands in for the load reported by consumers.
+//
+// A Producer that is closed or draining is removed from the pool the first time a write to it
+// fails, and the item is written to another Producer instead, unless WithPoolFailOnClosed is set.
+type ProducerPool[T any] struct {
+	mu             sync.Mutex
+	producers      []*pooledProducer[T]
+	kind           ProducerKind
+	fail_on_closed bool
+}
+
+// pooledProducer is a Producer in a ProducerPool with the time it was last selected.
+type pooledProducer[T any] struct {
+	producer *Producer[T]
+	lastUsed time.Time
+}
+
+// PoolOption configures a ProducerPool.
+type PoolOption[T any] func(*ProducerPool[T])
+
+// WithPoolFailOnClosed makes a write to a closed or draining Producer of the pool return
+// ErrProducerClosed instead of retrying with another Producer. The closed Producer is still
+// removed from the pool, so the next write goes elsewhere.
+func WithPoolFailOnClosed[T any]() PoolOption[T] {
+	return func(p *ProducerPool[T]) {
+		p.fail_on_closed = true
+	}
+}
+
+// NewProducerPool creates a new ProducerPool that writes to the given Producers with the given strategy.
+// It returns ErrUnsupportedKind for the kinds ProducerPool does not support.
+func NewProducerPool[T any](producers []*Producer[T], kind ProducerKind, options ...PoolOption[T]) (*ProducerPool[T], error) {
+	switch kind {
+	case ProducerKind_Single, ProducerKind_All, ProducerKind_LRU, ProducerKind_ReportedLoad:
+	default:
+		return nil, ErrUnsupportedKind
+	}
+
+	result := &ProducerPool[T]{kind: kind}
+	for _, producer := range producers {
+		result.producers = append(result.producers, &pooledProducer[T]{producer: producer})
+	}
+	for _, option := range options {
+		option(result)
+	}
+	return result, nil
+}
+
+// Write writes an item to the Producer selected by the pool's strategy without blocking.
+// It returns the error of that Producer's Write, or ErrProducerClosed once every Producer of
+// the pool is closed. Under ProducerKind_All it returns the first error other than
+// ErrProducerClosed, after writing to every open Producer.
+func (p *ProducerPool[T]) Write(item T) error {
+	if p.kind == ProducerKind_All {
+		return p.writeAll(item)
+	}
+	for {
+		p.mu.Lock()
+		target := p.selectProducer()
+		p.mu.Unlock()
+		if target == nil {
+			return ErrProducerClosed
+		}
+
+		err := target.Write(item)
+		if !errors.Is(err, ErrProducerClosed) {
+			return err
+		}
+		p.remove(target)
+		if p.fail_on_closed {
+			return err
+		}
+	}
+}
+
+// writeAll writes an item to every Producer of the pool, removing the closed ones.
+func (p *ProducerPool[T]) writeAll(item T) error {
+	p.mu.Lock()
+	targets := make([]*Producer[T], 0, len(p.producers))
+	for _, pooled := range p.producers {
+		targets = append(targets, pooled.producer)
+	}
+	p.mu.Unlock()
+
+	var firstErr error
+	written := false
+	for _, target := range targets {
+		err := target.Write(item)
+		switch {
+		case err == nil:
+			written = true
+		case errors.Is(err, ErrProducerClosed):
+			p.remove(target)
+			if p.fail_on_closed && firstErr == nil {
+				firstErr = err
+			}
+		case firstErr == nil:
+			firstErr = err
+		}
+	}
+	if firstErr == nil && !written {
+		return ErrProducerClosed
+	}
+	return firstErr
+}
+
+// selectProducer returns the Producer the next item is written to, or nil if the pool is empty.
+// It must be called with mu held.
+func (p *ProducerPool[T]) selectProducer() *Producer[T] {
+	if len(p.producers) == 0 {
+		return nil
+	}
+
+	var selected *pooledProducer[T]
+	switch p.kind {
+	case ProducerKind_Single:
+		selected = p.producers[rand.Intn(len(p.producers))]
+	case ProducerKind_ReportedLoad:
+		for _, pooled := range p.producers {
+			if selected == nil || pooled.producer.inputLen() < selected.producer.inputLen() {
+				selected = pooled
+			}
+		}
+	case ProducerKind_LRU:
+		for _, pooled := range p.producers {
+			if selected == nil || pooled.lastUsed.Before(selected.lastUsed) {
+				selected = pooled
+			}
+		}
+	}
+	selected.lastUsed = time.Now()
+	return selected.producer
+}
+
+// remove removes a Producer from the pool, if it is still in it.
+func (p *ProducerPool[T]) remove(producer *Producer[T]) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, pooled := range p.producers {
+		if pooled.producer == producer {
+			p.producers = append(p.producers[:i], p.producers[i+1:]...)
+			return
+		}
+	}
+}
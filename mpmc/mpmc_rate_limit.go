@@ -0,0 +1,59 @@
+package mpmc
+
+import (
+	"context"
+	"time"
+)
+
+// CreateConsumerRateLimited creates a new Consumer that receives at most itemsPerSec items per second.
+// Items delivered to it wait in its buffer and are released to Messages at that rate, so the
+// strategy keeps selecting the Consumer until its buffer is full, after which items are dropped
+// for it as usual. The pacing adds one goroutine per Consumer; DrainBuffered does not see items
+// that are still waiting to be released. A rate of 0 or less means no limit.
+func (f *Producer[T]) CreateConsumerRateLimited(ctx context.Context, itemsPerSec float64) (result *Consumer[T]) {
+	if itemsPerSec <= 0 {
+		return f.CreateConsumer(ctx)
+	}
+
+	pending := make(chan T, f.consumer_buffer_size)
+	messages := make(chan T)
+	result = newOutputConsumer(f, ctx, pending)
+	result.Messages = messages
+	result.paced = messages
+	result.pending = pending
+	result.interval = time.Duration(float64(time.Second) / itemsPerSec)
+	f.addConsumer(result)
+	return
+}
+
+// pace releases items from the pending buffer to Messages, at most one per interval,
+// until the Consumer is closed.
+func (c *Consumer[T]) pace(ctx context.Context) {
+	var next time.Time
+	timer := time.NewTimer(c.interval)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case item := <-c.pending:
+			if wait := time.Until(next); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case c.paced <- item:
+				next = time.Now().Add(c.interval)
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package mpmc
+
+import "context"
+
+// CreateConsumerEphemeral creates a new Consumer that only receives items dispatched after it
+// was created, even when a replay buffer is set with WithReplay. Consumers created any other
+// way are durable and are preloaded with the replay backlog.
+func (f *Producer[T]) CreateConsumerEphemeral(ctx context.Context) (result *Consumer[T]) {
+	result = newConsumer(f, ctx, f.consumer_buffer_size)
+	result.ephemeral = true
+	f.addConsumer(result)
+	return
+}
+
+// remember records a dispatched item in the replay buffer, replacing the oldest one once it is full.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) remember(item T) {
+	if len(f.replay) == 0 {
+		return
+	}
+	f.replay[f.replay_next] = item
+	f.replay_next = (f.replay_next + 1) % len(f.replay)
+	f.replay_filled = min(f.replay_filled+1, len(f.replay))
+}
+
+// preload delivers the replay backlog to a new durable Consumer, oldest first, keeping only the
+// most recent items if its buffer cannot hold them all.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) preload(c *Consumer[T]) {
+	if f.replay_filled == 0 || c.ephemeral {
+		return
+	}
+	capacity := c.bufferCap()
+	if c.queue != nil {
+		capacity = c.queue.max
+	}
+	skip := max(f.replay_filled-capacity, 0)
+	oldest := f.replay_next - f.replay_filled + len(f.replay)
+	preloaded := 0
+	for i := skip; i < f.replay_filled; i++ {
+		if !c.offer(f.replay[(oldest+i)%len(f.replay)]) {
+			break
+		}
+		preloaded++
+	}
+	f.logger.Debugln("Consumer", c.id, "preloaded with", preloaded, "replayed items")
+}
@@ -0,0 +1,111 @@
+package mpmc
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRetryQueueFull is returned by WriteRetry when the input buffer and the retry queue are both full.
+var ErrRetryQueueFull = errors.New("retry queue is full")
+
+// WriteRetry sends an item to the Producer's input channel, and parks it in the retry queue
+// if the input buffer is full. A background goroutine moves parked items to the input buffer
+// in order, waiting with exponential backoff while it stays full. Items are queued behind
+// earlier parked items, so WriteRetry keeps their order.
+// The retry queue is configured with WithRetryQueue; without it WriteRetry behaves like Write.
+// Items still parked when the Producer closes are discarded and counted as DropClosed.
+// It returns ErrRetryQueueFull if the item could not be parked, which counts as DropRetryOverflow,
+// ErrProducerClosed if the Producer is closed, and the sticky error of WithFailOnDrop.
+func (f *Producer[T]) WriteRetry(item T) error {
+	select {
+	case <-f.done:
+		return ErrProducerClosed
+	default:
+	}
+	if f.draining.Load() {
+		return ErrProducerClosed
+	}
+	if err := f.Err(); err != nil {
+		return err
+	}
+	if err := f.validate(item); err != nil {
+		return err
+	}
+
+	e := envelope[T]{item: item, enqueued: time.Now()}
+
+	f.retry_mu.Lock()
+	defer f.retry_mu.Unlock()
+	if len(f.retry_queue) == 0 && f.enqueue(e) {
+		return nil
+	}
+	if f.retry_size == 0 {
+		f.logger.Warnln("Producer buffer is full, dropping item")
+		f.dropped(DropInputFull, e.item)
+		return ErrBufferFull
+	}
+	if uint(len(f.retry_queue)) >= f.retry_size {
+		f.logger.Warnln("Retry queue is full, dropping item")
+		f.dropped(DropRetryOverflow, e.item)
+		return ErrRetryQueueFull
+	}
+
+	f.retry_queue = append(f.retry_queue, e)
+	select {
+	case f.retry_wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// goroutine_Producer_retry moves items parked by WriteRetry to the input buffer.
+func (f *Producer[T]) goroutine_Producer_retry() {
+	f.logger.Debugln("goroutine producer retry started")
+	backoff := f.retry_min_backoff
+	timer := time.NewTimer(backoff)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		f.retry_mu.Lock()
+		pending := len(f.retry_queue)
+		if pending > 0 {
+			select {
+			case f.input <- f.retry_queue[0]:
+				f.retry_queue[0] = envelope[T]{}
+				f.retry_queue = f.retry_queue[1:]
+				f.retry_mu.Unlock()
+				backoff = f.retry_min_backoff
+				continue
+			default:
+			}
+		}
+		f.retry_mu.Unlock()
+
+		var wait <-chan time.Time
+		if pending > 0 {
+			timer.Reset(backoff)
+			wait = timer.C
+			backoff = min(backoff*2, f.retry_max_backoff)
+		}
+
+		select {
+		case <-wait:
+		case <-f.retry_wake:
+			timer.Stop()
+		case <-f.done:
+			f.retry_mu.Lock()
+			discarded := len(f.retry_queue)
+			for _, e := range f.retry_queue {
+				f.dropped(DropClosed, e.item)
+			}
+			f.retry_queue = nil
+			f.retry_mu.Unlock()
+			if discarded > 0 {
+				f.logger.Warnln("Producer closed, discarded", discarded, "items from the retry queue")
+			}
+			f.logger.Debugln("goroutine Producer retry closing")
+			return
+		}
+	}
+}
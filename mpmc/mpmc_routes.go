@@ -0,0 +1,73 @@
+package mpmc
+
+import "errors"
+
+var (
+	// ErrUnknownRoute is returned by WriteRouted when no route with the given name exists.
+	ErrUnknownRoute = errors.New("unknown route")
+	// ErrUnsupportedKind is returned when a ProducerKind cannot be used in the requested context.
+	ErrUnsupportedKind = errors.New("unsupported producer kind")
+)
+
+// route is a named delivery rule: a fanout strategy applied to a subset of the consumers.
+type route struct {
+	kind        ProducerKind
+	consumerIDs map[string]bool
+}
+
+// AddRoute adds or replaces a named route that delivers with the given strategy to the given consumers.
+// Consumer IDs that are not attached are ignored until a consumer with that ID is attached.
+// It returns ErrUnsupportedKind for ProducerKind_Pull and unknown kinds.
+func (f *Producer[T]) AddRoute(name string, kind ProducerKind, consumerIDs []string) error {
+	if !kind.valid() || kind == ProducerKind_Pull {
+		return ErrUnsupportedKind
+	}
+
+	r := route{kind: kind, consumerIDs: make(map[string]bool, len(consumerIDs))}
+	for _, id := range consumerIDs {
+		r.consumerIDs[id] = true
+	}
+
+	f.consumers_mu.Lock()
+	f.routes[name] = r
+	f.consumers_mu.Unlock()
+
+	f.logger.Debugln("Route", name, "added with", len(consumerIDs), "consumers")
+	return nil
+}
+
+// RemoveRoute removes a named route. Items already written to it are dropped.
+func (f *Producer[T]) RemoveRoute(name string) {
+	f.consumers_mu.Lock()
+	delete(f.routes, name)
+	f.consumers_mu.Unlock()
+}
+
+// WriteRouted sends an item to the Producer's input channel to be delivered by the named route
+// instead of the Producer's own strategy. The fallback strategy applies to the route's consumers.
+// It returns ErrUnknownRoute if the route does not exist at the time of the call; an item whose
+// route is removed before it is dispatched is dropped with a warning.
+// It also returns an error if the Producer is closed or if the buffer is full.
+func (f *Producer[T]) WriteRouted(name string, item T) error {
+	f.consumers_mu.Lock()
+	_, ok := f.routes[name]
+	f.consumers_mu.Unlock()
+	if !ok {
+		f.logger.Warnln("Unknown route", name, "dropping item")
+		f.dropped(DropUnknownRoute, item)
+		return ErrUnknownRoute
+	}
+	return f.write(envelope[T]{item: item, route: name})
+}
+
+// routeConsumers returns the attached consumers that belong to the route.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) routeConsumers(r route) ConsumerList[T] {
+	result := make(ConsumerList[T], 0, len(r.consumerIDs))
+	for _, consumer := range f.consumers {
+		if r.consumerIDs[consumer.id] {
+			result = append(result, consumer)
+		}
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoMatchingConsumers is returned by WriteSelector when no attached consumer matches the selector.
+var ErrNoMatchingConsumers = errors.New("no consumers match selector")
+
+// Selector matches consumers whose tags contain every key with the given value.
+// An empty Selector matches every consumer.
+type Selector map[string]string
+
+// ParseSelector parses a selector of the form "key=value AND key=value".
+// Keys and values are trimmed of surrounding spaces; the AND keyword is case-insensitive.
+func ParseSelector(s string) (Selector, error) {
+	result := Selector{}
+	if strings.TrimSpace(s) == "" {
+		return result, nil
+	}
+	for _, term := range strings.Fields(s) {
+		if strings.EqualFold(term, "AND") {
+			continue
+		}
+		key, value, ok := strings.Cut(term, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid selector term %q", term)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// Matches reports whether the tags satisfy every equality of the Selector.
+func (s Selector) Matches(tags map[string]string) bool {
+	for key, value := range s {
+		if tag, ok := tags[key]; !ok || tag != value {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateConsumerWithTags creates a new Consumer with the given tags for WriteSelector.
+// The tags are copied. The Consumer also receives the items delivered by the Producer's strategy
+// like any other consumer.
+func (f *Producer[T]) CreateConsumerWithTags(ctx context.Context, tags map[string]string) (result *Consumer[T]) {
+	result = newConsumer(f, ctx, f.consumer_buffer_size)
+	result.tags = make(map[string]string, len(tags))
+	for key, value := range tags {
+		result.tags[key] = value
+	}
+	f.addConsumer(result)
+	return
+}
+
+// WriteSelector sends an item to the Producer's input channel to be delivered only to the consumers
+// whose tags match the selector. The Producer's strategy and fallback apply among the matching consumers.
+// It returns ErrNoMatchingConsumers if no consumer matches at the time of the call; an item whose
+// matching consumers are all removed before it is dispatched is dropped with a warning.
+// It also returns an error if the Producer is closed or if the buffer is full.
+func (f *Producer[T]) WriteSelector(sel Selector, item T) error {
+	f.consumers_mu.Lock()
+	matched := len(f.selectorConsumers(sel)) > 0
+	f.consumers_mu.Unlock()
+	if !matched {
+		f.logger.Warnln("No consumers match selector", sel, "dropping item")
+		f.dropped(DropNoConsumers, item)
+		return ErrNoMatchingConsumers
+	}
+	return f.write(envelope[T]{item: item, selector: sel})
+}
+
+// selectorConsumers returns the attached consumers whose tags match the selector.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) selectorConsumers(sel Selector) ConsumerList[T] {
+	result := ConsumerList[T]{}
+	for _, consumer := range f.consumers {
+		if sel.Matches(consumer.tags) {
+			result = append(result, consumer)
+		}
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoShardConsumers is returned by WriteShard when no attached consumer owns the shard.
+var ErrNoShardConsumers = errors.New("no consumers for shard")
+
+// CreateConsumerForShards creates a new Consumer that owns the given shards.
+// It only receives items written with WriteShard to one of its shards, in addition to the
+// items delivered by the Producer's strategy like any other consumer.
+func (f *Producer[T]) CreateConsumerForShards(ctx context.Context, shards ...int) (result *Consumer[T]) {
+	result = newConsumer(f, ctx, f.consumer_buffer_size)
+	result.shards = make(map[int]bool, len(shards))
+	for _, shard := range shards {
+		result.shards[shard] = true
+	}
+	f.addConsumer(result)
+	return
+}
+
+// WriteShard sends an item to the Producer's input channel to be delivered only to the consumers
+// that own the given shard. The Producer's strategy and fallback apply within the shard, so
+// ProducerKind_All broadcasts to the shard and the other strategies balance the load across it.
+// It returns ErrNoShardConsumers if no consumer owns the shard at the time of the call; an item
+// whose shard loses all its consumers before it is dispatched is dropped with a warning.
+// It also returns an error if the Producer is closed or if the buffer is full.
+func (f *Producer[T]) WriteShard(shard int, item T) error {
+	f.consumers_mu.Lock()
+	owned := len(f.shardConsumers(shard)) > 0
+	f.consumers_mu.Unlock()
+	if !owned {
+		f.logger.Warnln("No consumers for shard", shard, "dropping item")
+		f.dropped(DropNoConsumers, item)
+		return ErrNoShardConsumers
+	}
+	return f.write(envelope[T]{item: item, shard: shard, sharded: true})
+}
+
+// shardConsumers returns the attached consumers that own the shard.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) shardConsumers(shard int) ConsumerList[T] {
+	result := ConsumerList[T]{}
+	for _, consumer := range f.consumers {
+		if consumer.shards[shard] {
+			result = append(result, consumer)
+		}
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package mpmc
+
+// hold keeps an item in the startup buffer if no consumer was ever added and the buffer has room,
+// and parks an item written with WriteWhenReady while there is no consumer to deliver it to.
+// Writers waiting for the result of a held item are told that it was not delivered, while its
+// dispatch span, if any, stays open until the item is flushed or discarded.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) hold(e envelope[T]) bool {
+	if e.ready {
+		consumers := f.consumers
+		if f.manual_removal {
+			consumers = activeConsumers(consumers)
+		}
+		if len(consumers) == 0 {
+			e.tracked = nil
+			f.parked = append(f.parked, e)
+			return true
+		}
+	}
+	if f.started || len(f.consumers) > 0 || len(f.startup) == cap(f.startup) {
+		return false
+	}
+	e.tracked = nil
+	f.startup = append(f.startup, e)
+	return true
+}
+
+// flushStartup distributes the items held in the startup buffer once the first consumer is
+// added, oldest first, and releases the buffer so nothing is held afterwards.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) flushStartup() {
+	if f.started {
+		return
+	}
+	f.started = true
+	held := f.startup
+	f.startup = nil
+	if len(held) > 0 {
+		f.logger.Debugln("Flushing", len(held), "items held before the first consumer")
+	}
+	f.distributeHeld(held)
+}
+
+// flushParked distributes the items parked by WriteWhenReady once a consumer is added, oldest first.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) flushParked() {
+	held := f.parked
+	f.parked = nil
+	if len(held) > 0 {
+		f.logger.Debugln("Flushing", len(held), "items parked until a consumer was added")
+	}
+	f.distributeHeld(held)
+}
+
+// distributeHeld distributes held items and ends their dispatch spans.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) distributeHeld(held []envelope[T]) {
+	for _, e := range held {
+		result := f.distribute(e)
+		if result.delivered > 0 {
+			f.recordDelivery(e.enqueued)
+		}
+		if e.span != nil {
+			e.span.End(result.consumerID, result.delivered)
+		}
+	}
+}
+
+// discardStartup drops the items still held in the startup buffer when the Producer closes
+// before any consumer was added, and the items still parked by WriteWhenReady, counting them
+// as DropClosed.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) discardStartup() {
+	held := append(f.startup, f.parked...)
+	f.startup, f.parked = nil, nil
+	for _, e := range held {
+		f.dropped(DropClosed, e.item)
+		if e.span != nil {
+			e.span.End("", 0)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package mpmc
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ProducerStats is a snapshot of a Producer's counters.
+type ProducerStats struct {
+	// Delivered is the number of items delivered to at least one consumer.
+	Delivered uint64 `json:"delivered"`
+	// DispatchRestarts is the number of times the dispatch goroutine was restarted after a panic.
+	DispatchRestarts uint64 `json:"dispatch_restarts"`
+	// DroppedInputFull is the number of writes rejected because the input buffer was full,
+	// as counted for DropInputFull by DropCounts.
+	DroppedInputFull uint64 `json:"dropped_input_full"`
+	// DroppedConsumerFull is the number of items not delivered because the selected consumer's
+	// buffer was full, as counted for DropConsumerFull by DropCounts.
+	DroppedConsumerFull uint64 `json:"dropped_consumer_full"`
+	// DroppedNoConsumers is the number of items dispatched while no consumer was available,
+	// as counted for DropNoConsumers by DropCounts.
+	DroppedNoConsumers uint64 `json:"dropped_no_consumers"`
+	// Filtered is the number of items discarded by the input filter set with WithInputFilter.
+	// They are not counted as drops.
+	Filtered uint64 `json:"filtered"`
+	// Overflowed is the number of dropped items caught by the overflow consumer set with
+	// SetOverflowConsumer. They are not counted as drops.
+	Overflowed uint64 `json:"overflowed"`
+	// SelectionAttempts is the number of times dispatch offered an item to a consumer's buffer,
+	// including the attempts that failed because the buffer was full.
+	SelectionAttempts uint64 `json:"selection_attempts"`
+	// SelectionAccepted is the number of those attempts that the consumer accepted.
+	SelectionAccepted uint64 `json:"selection_accepted"`
+	// AttemptsPerDelivery is SelectionAttempts divided by SelectionAccepted, or 0 before the
+	// first accepted attempt. It is 1 when consumers always have room; much more than 1 means
+	// selected consumers are often full.
+	AttemptsPerDelivery float64 `json:"attempts_per_delivery"`
+	// RetryPending is the number of items parked in the retry queue by WriteRetry.
+	RetryPending int `json:"retry_pending"`
+	// QueueLatencyAvg is the average time delivered items spent between being written and
+	// being delivered to their first consumer.
+	QueueLatencyAvg time.Duration `json:"queue_latency_avg"`
+	// QueueLatencyMax is the longest time a delivered item spent between being written and
+	// being delivered to its first consumer.
+	QueueLatencyMax time.Duration `json:"queue_latency_max"`
+	// LockHolds is the number of times the dispatch goroutine locked the consumer list to
+	// dispatch items. It and the hold times are only measured with WithLockTiming.
+	LockHolds uint64 `json:"lock_holds"`
+	// LockHoldAvg is the average time the dispatch goroutine held the consumer list lock.
+	LockHoldAvg time.Duration `json:"lock_hold_avg"`
+	// LockHoldMax is the longest time the dispatch goroutine held the consumer list lock.
+	LockHoldMax time.Duration `json:"lock_hold_max"`
+}
+
+// Stats returns a snapshot of the Producer's counters.
+func (f *Producer[T]) Stats() ProducerStats {
+	return f.stats(false)
+}
+
+// StatsAndReset returns a snapshot of the Producer's counters and resets them to zero, for
+// computing per-interval rates. Each counter is read and reset with a single atomic swap, so
+// no increment is lost or counted in two intervals. The drop counts reported by DropCounts are
+// reset too. RetryPending is a current level rather than a counter and is not reset.
+func (f *Producer[T]) StatsAndReset() ProducerStats {
+	return f.stats(true)
+}
+
+// stats returns a snapshot of the Producer's counters, swapping them with zero if reset is set.
+func (f *Producer[T]) stats(reset bool) ProducerStats {
+	read := func(counter *atomic.Int64) int64 {
+		if reset {
+			return counter.Swap(0)
+		}
+		return counter.Load()
+	}
+	readUnsigned := func(counter *atomic.Uint64) uint64 {
+		if reset {
+			return counter.Swap(0)
+		}
+		return counter.Load()
+	}
+
+	result := ProducerStats{
+		Delivered:           readUnsigned(&f.delivered),
+		DispatchRestarts:    readUnsigned(&f.dispatch_restarts),
+		DroppedInputFull:    readUnsigned(&f.drop_counts[DropInputFull]),
+		DroppedConsumerFull: readUnsigned(&f.drop_counts[DropConsumerFull]),
+		DroppedNoConsumers:  readUnsigned(&f.drop_counts[DropNoConsumers]),
+		Filtered:            readUnsigned(&f.drop_counts[DropFiltered]),
+		Overflowed:          readUnsigned(&f.overflow_count),
+		SelectionAttempts:   readUnsigned(&f.send_attempts),
+		SelectionAccepted:   readUnsigned(&f.send_accepted),
+		QueueLatencyMax:     time.Duration(read(&f.latency_max)),
+	}
+	if result.SelectionAccepted > 0 {
+		result.AttemptsPerDelivery = float64(result.SelectionAttempts) / float64(result.SelectionAccepted)
+	}
+	if reset {
+		for reason := DropReason(0); reason < dropReasonCount; reason++ {
+			switch reason {
+			case DropFiltered, DropInputFull, DropConsumerFull, DropNoConsumers:
+				// Already read and reset above
+			default:
+				f.drop_counts[reason].Swap(0)
+			}
+		}
+	}
+
+	f.retry_mu.Lock()
+	result.RetryPending = len(f.retry_queue)
+	f.retry_mu.Unlock()
+
+	// The total and the count are swapped one after the other, so an item recorded in between
+	// can move the average slightly, but it is still counted exactly once
+	total, count := read(&f.latency_total), read(&f.latency_count)
+	if count > 0 {
+		result.QueueLatencyAvg = time.Duration(total / count)
+	}
+	held, holds := read(&f.lock_total), read(&f.lock_count)
+	if holds > 0 {
+		result.LockHolds = uint64(holds)
+		result.LockHoldAvg = time.Duration(held / holds)
+	}
+	result.LockHoldMax = time.Duration(read(&f.lock_max))
+	return result
+}
+
+// recordDelivery counts a delivered item and records its time-in-queue, given the time it was written.
+func (f *Producer[T]) recordDelivery(enqueued time.Time) {
+	f.delivered.Add(1)
+	if enqueued.IsZero() {
+		return
+	}
+	latency := int64(time.Since(enqueued))
+	if f.latency_histogram != nil {
+		f.latency_histogram.record(time.Duration(latency))
+	}
+	f.latency_total.Add(latency)
+	f.latency_count.Add(1)
+	for {
+		current := f.latency_max.Load()
+		if latency <= current || f.latency_max.CompareAndSwap(current, latency) {
+			return
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package mpmc
+
+import (
+	"time"
+
+	"github.com/Moonlight-Companies/gompmc/logger"
+)
+
+// StatsString returns a summary of the Producer formatted with logger.PrettyMap, one
+// "key: value" line each for the sequence number of the last dispatched item, the items
+// delivered and dropped, the number of consumers, and the items waiting in the input buffer.
+// Filtered items are not counted as dropped.
+func (f *Producer[T]) StatsString() string {
+	var dropped uint64
+	for reason, count := range f.DropCounts() {
+		if reason != DropFiltered {
+			dropped += count
+		}
+	}
+	f.consumers_mu.Lock()
+	consumers := len(f.consumers)
+	f.consumers_mu.Unlock()
+
+	return logger.PrettyMap(map[string]interface{}{
+		"sequence":  f.Sequence(),
+		"delivered": f.Stats().Delivered,
+		"dropped":   dropped,
+		"consumers": consumers,
+		"buffered":  f.inputLen(),
+	}, "  ")
+}
+
+// goroutine_Producer_stats_logging logs StatsString at INFO level at each interval.
+func (f *Producer[T]) goroutine_Producer_stats_logging() {
+	f.logger.Debugln("goroutine producer stats logging started")
+	ticker := time.NewTicker(f.stats_interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			f.logger.Infoln("Stats:\n" + f.StatsString())
+		case <-f.done:
+			f.logger.Debugln("goroutine Producer stats logging closing")
+			return
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package mpmc
+
+import "context"
+
+// DispatchTracer starts a span around the dispatch of each item written with WriteTraced.
+// It keeps tracing libraries out of this package; the otelmpmc module provides an OpenTelemetry
+// implementation that starts a child span named "mpmc.dispatch" from ctx.
+type DispatchTracer interface {
+	StartDispatch(ctx context.Context, strategy ProducerKind) DispatchSpan
+}
+
+// DispatchSpan is the span of one dispatched item, ended once the item has been buffered by a
+// consumer or dropped. The span of an item held by WithStartupBuffer ends when it is flushed.
+type DispatchSpan interface {
+	// End ends the span. consumerID is the consumer that received the item, or the first one
+	// under the All strategy, and is empty if the item was dropped; delivered is the number of
+	// consumers that received it, as reported by WriteTracked and WriteToLRU.
+	End(consumerID string, delivered int)
+}
+
+// WriteTraced sends an item to the Producer's input channel like Write, carrying ctx so that its
+// dispatch is traced by the DispatchTracer set with WithDispatchTracer. Without a tracer, and in
+// ProducerKind_Pull mode, it behaves exactly like Write.
+func (f *Producer[T]) WriteTraced(ctx context.Context, item T) error {
+	return f.write(envelope[T]{item: item, ctx: ctx})
+}
+
+// startSpan starts the dispatch span of an item, or returns nil if the item is not traced.
+// Routed items report the strategy of their route.
+// It must be called with consumers_mu held.
+func (f *Producer[T]) startSpan(e envelope[T]) DispatchSpan {
+	if f.tracer == nil || e.ctx == nil {
+		return nil
+	}
+	kind := f.kind
+	if route, ok := f.routes[e.route]; ok && e.route != "" {
+		kind = route.kind
+	}
+	return f.tracer.StartDispatch(e.ctx, kind)
+}
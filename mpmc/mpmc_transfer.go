@@ -0,0 +1,88 @@
+package mpmc
+
+import "errors"
+
+// ErrUnknownConsumer is returned when no attached consumer has the given ID.
+var ErrUnknownConsumer = errors.New("unknown consumer")
+
+// TransferBuffer decommissions the consumer fromID without losing its buffered items: the
+// consumer is detached from the Producer, so that dispatch can no longer deliver to it, the
+// items left in its buffer are delivered to the consumer toID, oldest first, and it is closed.
+// Items that do not fit in the destination's buffer are dropped for DropConsumerFull, or
+// handed to the overflow consumer. It returns the number of items moved, or ErrUnknownConsumer
+// if either consumer is not attached, in which case nothing is changed.
+// Items the source's readers take while the transfer runs are not moved, and a source created
+// with AddOutputChannel has no buffer of its own to move.
+func (f *Producer[T]) TransferBuffer(fromID, toID string) (moved int, err error) {
+	f.consumers_mu.Lock()
+	defer f.consumers_mu.Unlock()
+
+	from, to := f.findConsumer(fromID), f.findConsumer(toID)
+	if from == nil || to == nil || from == to {
+		return 0, ErrUnknownConsumer
+	}
+	// Dispatch holds consumers_mu too, so nothing is delivered to the source from here on
+	f.removeConsumer(from)
+	items := from.takeBuffered()
+	from.Close()
+
+	for _, item := range items {
+		if f.send(to, item) {
+			moved++
+			continue
+		}
+		f.dropped(DropConsumerFull, item)
+	}
+	f.logger.Debugln("Moved", moved, "items from consumer", fromID, "to consumer", toID)
+	return moved, nil
+}
+
+// takeBuffered removes and returns every item waiting for the Consumer's readers, oldest first.
+// The buffering goroutine of adaptive, priority and rate-limited buffers may still hold the one
+// item it is handing over to Messages, which is picked up when it lands in Messages in time.
+func (c *Consumer[T]) takeBuffered() (result []T) {
+	c.eachBuffered(func(item T) {
+		result = append(result, item)
+	})
+	return
+}
+
+// eachBuffered removes every item waiting for the Consumer's readers and passes it to fn,
+// in the order takeBuffered returns them, without collecting them first.
+func (c *Consumer[T]) eachBuffered(fn func(T)) {
+	c.eachMessage(fn)
+	if c.queue != nil {
+		for item, ok := c.queue.pop(); ok; item, ok = c.queue.pop() {
+			fn(item)
+		}
+	}
+	if c.ranked != nil {
+		for item, ok := c.ranked.pop(); ok; item, ok = c.ranked.pop() {
+			fn(item)
+		}
+	}
+	if c.pending != nil {
+	pending:
+		for {
+			select {
+			case item := <-c.pending:
+				fn(item)
+			default:
+				break pending
+			}
+		}
+	}
+	c.eachMessage(fn)
+}
+
+// eachMessage removes the items currently buffered in Messages without blocking and passes each to fn.
+func (c *Consumer[T]) eachMessage(fn func(T)) {
+	for {
+		select {
+		case item := <-c.Messages:
+			fn(item)
+		default:
+			return
+		}
+	}
+}
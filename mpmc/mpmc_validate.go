@@ -0,0 +1,15 @@
+package mpmc
+
+// validate runs the validator set with WithValidator on an item on the writer's goroutine.
+// It returns the validator's error, counting the item as DropInvalid, or nil if the item is valid.
+func (f *Producer[T]) validate(item T) error {
+	if f.validator == nil {
+		return nil
+	}
+	if err := f.validator(item); err != nil {
+		f.logger.Debugln("Invalid item rejected:", err)
+		f.drop_counts[DropInvalid].Add(1)
+		return err
+	}
+	return nil
+}
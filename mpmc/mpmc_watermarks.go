@@ -0,0 +1,29 @@
+package mpmc
+
+// inputLen returns the number of items waiting in the input buffer.
+func (f *Producer[T]) inputLen() int {
+	if f.unbounded != nil {
+		return len(f.input) + f.unbounded.len()
+	}
+	return len(f.input)
+}
+
+// checkWatermarks calls the watermark callbacks set with WithInputWatermarks when the input
+// buffer has crossed the high watermark on the way up or the low watermark on the way down.
+func (f *Producer[T]) checkWatermarks() {
+	if f.watermark_high == 0 {
+		return
+	}
+	length := f.inputLen()
+	if length >= f.watermark_high && f.above_high.CompareAndSwap(false, true) {
+		f.logger.Debugln("Input buffer reached the high watermark with", length, "items")
+		if f.on_high != nil {
+			f.on_high()
+		}
+	} else if length <= f.watermark_low && f.above_high.CompareAndSwap(true, false) {
+		f.logger.Debugln("Input buffer fell to the low watermark with", length, "items")
+		if f.on_low != nil {
+			f.on_low()
+		}
+	}
+}
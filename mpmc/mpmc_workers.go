@@ -0,0 +1,69 @@
+package mpmc
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultWorkerBuffer is the size of the input buffer and of each consumer's buffer of a
+// Producer created by RunWorkers.
+const DefaultWorkerBuffer = 64
+
+// RunWorkers creates a Producer with n consumers, each served by a goroutine that calls handle
+// for every item it receives, which is the usual way to fan items out to worker functions.
+// The returned wait function blocks until every worker has stopped and returns the first error
+// returned by a handler, ctx.Err() if ctx was cancelled, or nil if the Producer was closed.
+// The first handler error stops every worker and closes the Producer, so that later writes fail
+// instead of piling up. When the Producer closes, each worker handles the items left in its
+// buffer before stopping, so calling CloseDraining and then wait handles every written item.
+// Buffers hold DefaultWorkerBuffer items; options apply to the Producer as with NewProducer.
+func RunWorkers[T any](ctx context.Context, kind ProducerKind, n int, handle func(T) error, options ...Option[T]) (*Producer[T], func() error) {
+	result := NewProducer[T](kind, DefaultWorkerBuffer, DefaultWorkerBuffer, options...)
+	ctx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	var firstErr error
+	errOnce := sync.Once{}
+	for i := 0; i < n; i++ {
+		consumer := result.CreateConsumer(ctx)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := consumer.runWorker(ctx, handle); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+					result.Close()
+				})
+			}
+		}()
+	}
+
+	return result, func() error {
+		wg.Wait()
+		cancel()
+		return firstErr
+	}
+}
+
+// runWorker calls handle for every item received until handle returns an error, ctx is cancelled,
+// or the Consumer is closed, in which case the items left in its buffer are handled first.
+func (c *Consumer[T]) runWorker(ctx context.Context, handle func(T) error) error {
+	for {
+		select {
+		case item := <-c.Messages:
+			if err := handleTimed(c, handle, item); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.ctx.Done():
+			for _, item := range c.DrainBuffered() {
+				if err := handleTimed(c, handle, item); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package otelmpmc traces the dispatch of mpmc items with OpenTelemetry.
+// It is a separate module so that only programs importing it depend on OpenTelemetry.
+package otelmpmc
+
+import (
+	"context"
+
+	"github.com/Moonlight-Companies/gompmc/mpmc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the tracer used by NewTracer.
+const ScopeName = "github.com/Moonlight-Companies/gompmc/mpmc/otelmpmc"
+
+// SpanName is the name of the span started for the dispatch of each traced item.
+const SpanName = "mpmc.dispatch"
+
+// Attribute keys set on dispatch spans.
+const (
+	// AttributeStrategy is the fanout strategy that dispatched the item.
+	AttributeStrategy = attribute.Key("mpmc.strategy")
+	// AttributeConsumerID is the consumer that received the item, or the first one under the
+	// All strategy. It is not set on dropped items.
+	AttributeConsumerID = attribute.Key("mpmc.consumer_id")
+	// AttributeDelivered is the number of consumers that received the item.
+	AttributeDelivered = attribute.Key("mpmc.delivered")
+	// AttributeOutcome is "delivered" or "dropped".
+	AttributeOutcome = attribute.Key("mpmc.outcome")
+)
+
+// Tracer is an mpmc.DispatchTracer that starts a child span named SpanName of the context
+// passed to Producer.WriteTraced for each dispatched item.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer creates a Tracer using the given TracerProvider, or the global one if it is nil.
+// Pass it to mpmc.WithDispatchTracer.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: provider.Tracer(ScopeName)}
+}
+
+// StartDispatch starts the span of one dispatched item.
+// This is part of mpmc.DispatchTracer.
+func (t *Tracer) StartDispatch(ctx context.Context, strategy mpmc.ProducerKind) mpmc.DispatchSpan {
+	_, span := t.tracer.Start(ctx, SpanName,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(AttributeStrategy.String(strategy.String())))
+	return dispatchSpan{span: span}
+}
+
+// dispatchSpan is the span of one dispatched item.
+type dispatchSpan struct {
+	span trace.Span
+}
+
+// End records where the item went and ends the span.
+// This is part of mpmc.DispatchSpan.
+func (s dispatchSpan) End(consumerID string, delivered int) {
+	if delivered > 0 {
+		s.span.SetAttributes(
+			AttributeConsumerID.String(consumerID),
+			AttributeDelivered.Int(delivered),
+			AttributeOutcome.String("delivered"))
+	} else {
+		s.span.SetAttributes(AttributeDelivered.Int(0), AttributeOutcome.String("dropped"))
+	}
+	s.span.End()
+}
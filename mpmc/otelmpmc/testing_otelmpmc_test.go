@@ -0,0 +1,56 @@
+package otelmpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gompmc/mpmc"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	fanout := mpmc.NewProducer[int](mpmc.ProducerKind_LRU, 16, 16, mpmc.WithDispatchTracer[int](NewTracer(provider)))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	parentCtx, parent := provider.Tracer("test").Start(ctx, "parent")
+
+	// next waits for the next span to end and returns its attributes
+	next := func() (sdktrace.ReadOnlySpan, map[attribute.Key]attribute.Value) {
+		for len(recorder.Ended()) == 0 {
+			if ctx.Err() != nil {
+				t.Fatal("Missing span")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		span := recorder.Ended()[0]
+		recorder.Reset()
+		attributes := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			attributes[kv.Key] = kv.Value
+		}
+		return span, attributes
+	}
+
+	fanout.WriteTraced(parentCtx, 0)
+	span, attributes := next()
+	if span.Name() != SpanName || span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("Span %q has parent %v, expected %q with parent %v", span.Name(), span.Parent().SpanID(), SpanName, parent.SpanContext().SpanID())
+	}
+	if attributes[AttributeOutcome].AsString() != "dropped" || attributes[AttributeStrategy].AsString() != "lru" {
+		t.Errorf("Dropped item has attributes %v", attributes)
+	}
+
+	consumer := fanout.CreateConsumer(ctx)
+	fanout.WriteTraced(parentCtx, 1)
+	_, attributes = next()
+	if attributes[AttributeOutcome].AsString() != "delivered" || attributes[AttributeConsumerID].AsString() != consumer.Id() || attributes[AttributeDelivered].AsInt64() != 1 {
+		t.Errorf("Delivered item has attributes %v", attributes)
+	}
+}
@@ -0,0 +1,48 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveBufferGrowAndShrink(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 64, 0, WithAdaptiveBuffer[int](2, 16))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	if capacity := consumer.bufferCap(); capacity != 2 {
+		t.Errorf("Initial buffer capacity is %d, expected 2", capacity)
+	}
+
+	// The pump goroutine may hold one item while waiting on Messages, so 17 items fit at most
+	accepted := 0
+	for i := 0; i < 32; i++ {
+		if id, _ := fanout.WriteTracked(i); id != "" {
+			accepted++
+		}
+	}
+	if accepted < 16 || accepted > 17 {
+		t.Errorf("Consumer accepted %d items, expected 16 or 17", accepted)
+	}
+	if capacity := consumer.bufferCap(); capacity != 16 {
+		t.Errorf("Buffer capacity under pressure is %d, expected 16", capacity)
+	}
+
+	for i := 0; i < accepted; i++ {
+		select {
+		case item := <-consumer.Messages:
+			if item != i {
+				t.Errorf("Consumer received %d, expected %d", item, i)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Consumer did not receive item %d", i)
+		}
+	}
+	if capacity := consumer.bufferCap(); capacity != 2 {
+		t.Errorf("Buffer capacity after draining is %d, expected 2", capacity)
+	}
+}
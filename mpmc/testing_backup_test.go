@@ -0,0 +1,51 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackupSpill(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	// A replica that can only ever hold two items, backed by a regular consumer
+	replica := make(chan int, 2)
+	fanout.AddOutputChannel(replica)
+	backup := fanout.CreateConsumer(ctx)
+
+	if err := fanout.WaitForConsumers(ctx, 2); err != nil {
+		t.Fatalf("WaitForConsumers returned %v", err)
+	}
+
+	var replicaID string
+	fanout.consumers_mu.Lock()
+	for _, consumer := range fanout.consumers {
+		if consumer != backup {
+			replicaID = consumer.id
+		}
+	}
+	fanout.consumers_mu.Unlock()
+	fanout.SetBackup(replicaID, backup.Id())
+
+	numItems := 5
+	for i := 0; i < numItems; i++ {
+		if err := fanout.Write(i); err != nil {
+			t.Fatalf("Write returned %v", err)
+		}
+	}
+
+	// The backup gets its own copy of every item plus the items the replica had no room for
+	expected := numItems + numItems - cap(replica)
+	for received := 0; received < expected; received++ {
+		select {
+		case <-backup.Messages:
+		case <-ctx.Done():
+			t.Fatalf("Backup received %d items, expected %d", received, expected)
+		}
+	}
+}
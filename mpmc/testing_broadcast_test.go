@@ -0,0 +1,76 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWriteAllSync(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 1)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	fast := fanout.CreateConsumer(ctx)
+	slow := fanout.CreateConsumer(ctx)
+
+	// The slow consumer's buffer is full
+	fanout.WriteTracked(0)
+	<-fast.Messages
+
+	written := make(chan error, 1)
+	go func() {
+		written <- fanout.WriteAllSync(ctx, 1)
+	}()
+
+	if item := <-fast.Messages; item != 1 {
+		t.Errorf("Fast consumer received %d, expected 1", item)
+	}
+	select {
+	case err := <-written:
+		t.Fatalf("WriteAllSync returned %v before the slow consumer had room", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if item := <-slow.Messages; item != 0 {
+		t.Errorf("Slow consumer received %d, expected 0", item)
+	}
+	if err := <-written; err != nil {
+		t.Errorf("WriteAllSync returned %v", err)
+	}
+	if item := <-slow.Messages; item != 1 {
+		t.Errorf("Slow consumer received %d, expected 1", item)
+	}
+
+	// A consumer that leaves is not waited for, ctx is
+	fanout.WriteAllSync(ctx, 2)
+	<-fast.Messages
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		slow.Close()
+	}()
+	if err := fanout.WriteAllSync(ctx, 3); err != nil {
+		t.Errorf("WriteAllSync returned %v after the full consumer closed", err)
+	}
+	short, cancelShort := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancelShort()
+	if err := fanout.WriteAllSync(short, 4); err != context.DeadlineExceeded {
+		t.Errorf("WriteAllSync returned %v with a full consumer, expected context.DeadlineExceeded", err)
+	}
+
+	empty := NewProducer[int](ProducerKind_All, 1, 1)
+	defer empty.Close()
+	if err := empty.WriteAllSync(ctx, 0); err != ErrNoConsumers {
+		t.Errorf("WriteAllSync without consumers returned %v, expected ErrNoConsumers", err)
+	}
+	if dropped := empty.DropCounts()[DropNoConsumers]; dropped != 1 {
+		t.Errorf("WriteAllSync without consumers counted %d drops, expected 1", dropped)
+	}
+
+	single := NewProducer[int](ProducerKind_Single, 1, 1)
+	defer single.Close()
+	if err := single.WriteAllSync(ctx, 0); err != ErrUnsupportedKind {
+		t.Errorf("WriteAllSync under Single returned %v, expected ErrUnsupportedKind", err)
+	}
+}
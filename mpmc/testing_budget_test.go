@@ -0,0 +1,50 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWriteBudget(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 2, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	consumer := fanout.CreateConsumer(ctx)
+
+	// Saturate the input buffer while dispatch is paused
+	fanout.Pause()
+	budget := 20 * time.Millisecond
+	for i := 0; i < 2; i++ {
+		if err := fanout.WriteBudget(i, budget); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now()
+	if err := fanout.WriteBudget(2, budget); err != ErrBudgetExceeded {
+		t.Errorf("WriteBudget to a full buffer returned %v, expected ErrBudgetExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed < budget {
+		t.Errorf("WriteBudget gave up after %v, before its budget of %v", elapsed, budget)
+	}
+
+	// The buffered items are past their budget by the time they are dispatched
+	fanout.Resume()
+	if err := fanout.WriteBudget(3, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case item := <-consumer.Messages:
+		if item != 3 {
+			t.Errorf("Received %d, expected only the item within its budget", item)
+		}
+	case <-ctx.Done():
+		t.Fatal("Item within its budget was not delivered")
+	}
+	counts := fanout.DropCounts()
+	if counts[DropBudgetExceeded] != 3 || counts[DropInputFull] != 0 {
+		t.Errorf("DropCounts is %v, expected 3 items over their budget", counts)
+	}
+}
@@ -0,0 +1,55 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus[int](ProducerKind_LRU, 64, 64)
+	defer bus.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumers := []*Consumer[int]{bus.CreateConsumer(ctx), bus.CreateConsumer(ctx)}
+
+	first := NewProducer[int](ProducerKind_LRU, 16, 16)
+	second := NewProducer[int](ProducerKind_LRU, 16, 16)
+	defer second.Close()
+	bus.Attach(first)
+	bus.Attach(second)
+
+	receive := func(n int) (items []int) {
+		for len(items) < n {
+			select {
+			case item := <-consumers[0].Messages:
+				items = append(items, item)
+			case item := <-consumers[1].Messages:
+				items = append(items, item)
+			case <-ctx.Done():
+				t.Fatalf("Received %v, expected %d items", items, n)
+			}
+		}
+		return
+	}
+
+	for i := 0; i < 4; i++ {
+		first.WriteTracked(i)
+		second.WriteTracked(10 + i)
+	}
+	receive(8)
+	if consumers[0].Stats().Received == 0 || consumers[1].Stats().Received == 0 {
+		t.Error("Bus strategy did not spread items over the shared pool")
+	}
+
+	// Closing one producer leaves the shared consumers to the others
+	if err := first.ShutdownOrdered(ctx); err != nil {
+		t.Fatalf("ShutdownOrdered returned %v", err)
+	}
+	second.WriteTracked(20)
+	if items := receive(1); items[0] != 20 {
+		t.Errorf("Received %v, expected 20", items)
+	}
+}
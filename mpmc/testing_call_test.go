@@ -0,0 +1,68 @@
+package mpmc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+type request struct {
+	id    string
+	value string
+}
+
+func TestCall(t *testing.T) {
+	fanout := NewProducer[request](ProducerKind_Single, 16, 16, WithCorrelation(func(r request, id string) request {
+		r.id = id
+		return r
+	}))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	go func() {
+		for {
+			select {
+			case req := <-consumer.Messages:
+				fanout.Respond(req.id, request{value: strings.ToUpper(req.value)})
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	resp, err := fanout.Call(ctx, request{value: "ping"})
+	if err != nil {
+		t.Fatalf("Call returned %v", err)
+	}
+	if resp.value != "PING" {
+		t.Errorf("Call returned %q, expected %q", resp.value, "PING")
+	}
+	if len(fanout.calls) != 0 {
+		t.Errorf("%d calls still pending after Call returned", len(fanout.calls))
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	fanout := NewProducer[request](ProducerKind_Single, 16, 16, WithCorrelation(func(r request, id string) request {
+		r.id = id
+		return r
+	}))
+	defer fanout.Close()
+
+	consumer := fanout.CreateConsumer(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := fanout.Call(ctx, request{value: "ping"}); err != context.DeadlineExceeded {
+		t.Errorf("Call returned %v, expected %v", err, context.DeadlineExceeded)
+	}
+
+	req := <-consumer.Messages
+	if err := fanout.Respond(req.id, request{}); err != ErrUnknownCall {
+		t.Errorf("Late Respond returned %v, expected %v", err, ErrUnknownCall)
+	}
+}
@@ -0,0 +1,68 @@
+package mpmc
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// CollectAll reads every consumer concurrently until it has received no item for the quiet
+// period d, or until it is closed, and returns the items each one received, in consumer order.
+func CollectAll[T any](consumers []*Consumer[T], d time.Duration) [][]T {
+	results := make([][]T, len(consumers))
+	var wg sync.WaitGroup
+	for i, consumer := range consumers {
+		wg.Add(1)
+		go func(i int, c *Consumer[T]) {
+			defer wg.Done()
+			quiet := time.NewTimer(d)
+			defer quiet.Stop()
+			for {
+				select {
+				case item := <-c.Messages:
+					results[i] = append(results[i], item)
+					if !quiet.Stop() {
+						<-quiet.C
+					}
+					quiet.Reset(d)
+				case <-quiet.C:
+					return
+				case <-c.ctx.Done():
+					return
+				}
+			}
+		}(i, consumer)
+	}
+	wg.Wait()
+	return results
+}
+
+func TestCollectAll(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	consumers := []*Consumer[int]{fanout.CreateConsumer(ctx), fanout.CreateConsumer(ctx), fanout.CreateConsumer(ctx)}
+
+	go func() {
+		for i := 0; i < 4; i++ {
+			time.Sleep(5 * time.Millisecond)
+			fanout.Write(i)
+		}
+	}()
+
+	start := time.Now()
+	results := CollectAll(consumers, 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("CollectAll took %v, expected it to stop after the quiet period", elapsed)
+	}
+	expected := [][]int{{0, 3}, {1}, {2}}
+	for i := range expected {
+		if !slices.Equal(results[i], expected[i]) {
+			t.Errorf("Consumer %d received %v, expected %v", i, results[i], expected[i])
+		}
+	}
+}
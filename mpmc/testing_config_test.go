@@ -0,0 +1,46 @@
+package mpmc
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestNewProducerFromConfig(t *testing.T) {
+	var config Config
+	data := `{"kind": 2, "fallback": 0, "input_buffer": 8, "consumer_buffer": 4, "log_level": 1}`
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatalf("Unmarshal returned %v", err)
+	}
+
+	fanout, err := NewProducerFromConfig[int](config)
+	if err != nil {
+		t.Fatalf("NewProducerFromConfig returned %v", err)
+	}
+	defer fanout.Close()
+
+	if fanout.kind != ProducerKind_All {
+		t.Errorf("Producer kind is %d, expected %d", fanout.kind, ProducerKind_All)
+	}
+	if !fanout.has_fallback || fanout.fallback != ProducerKind_Single {
+		t.Errorf("Producer fallback is %d (%v), expected %d", fanout.fallback, fanout.has_fallback, ProducerKind_Single)
+	}
+	if cap(fanout.input) != 8 || fanout.consumer_buffer_size != 4 {
+		t.Errorf("Producer buffers are %d/%d, expected 8/4", cap(fanout.input), fanout.consumer_buffer_size)
+	}
+}
+
+func TestNewProducerFromConfigInvalid(t *testing.T) {
+	unknown := ProducerKind(99)
+	configs := []Config{
+		{Kind: unknown, InputBuffer: 1, ConsumerBuffer: 1},
+		{Kind: ProducerKind_All, Fallback: &unknown, InputBuffer: 1, ConsumerBuffer: 1},
+		{Kind: ProducerKind_All, InputBuffer: 1, ConsumerBuffer: 1, LogLevel: 99},
+	}
+
+	for i, config := range configs {
+		if _, err := NewProducerFromConfig[int](config); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("Config %d returned %v, expected %v", i, err, ErrInvalidConfig)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package mpmc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSortedConsumerInfo(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	numConsumers := 5
+	ids := make([]string, numConsumers)
+	for i := 0; i < numConsumers; i++ {
+		ids[i] = fanout.CreateConsumer(ctx).Id()
+	}
+
+	// LRU dispatch reorders the internal list
+	for i := 0; i < 10; i++ {
+		fanout.Write(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	infos := fanout.SortedConsumerInfo()
+	if len(infos) != numConsumers {
+		t.Fatalf("SortedConsumerInfo returned %d consumers, expected %d", len(infos), numConsumers)
+	}
+	for i, info := range infos {
+		if info.ID != ids[i] {
+			t.Errorf("Consumer %d is %s, expected %s", i, info.ID, ids[i])
+		}
+		if info.BufferCap != 16 {
+			t.Errorf("Consumer %d buffer capacity is %d, expected 16", i, info.BufferCap)
+		}
+	}
+}
+
+func TestTopologyJSON(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 8, 4)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	named := fanout.GetOrCreateConsumer(ctx, "audit")
+	tagged := fanout.CreateConsumerWithTags(ctx, map[string]string{"region": "eu"})
+	fanout.WriteTracked(1)
+
+	data, err := fanout.TopologyJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var topology map[string]any
+	if err := json.Unmarshal(data, &topology); err != nil {
+		t.Fatalf("TopologyJSON returned invalid JSON: %v", err)
+	}
+	for _, field := range []string{"kind", "input_len", "input_cap", "consumer_buffer_size", "stats", "consumers"} {
+		if _, ok := topology[field]; !ok {
+			t.Errorf("Topology has no %s field: %s", field, data)
+		}
+	}
+	if topology["kind"] != "lru" || topology["input_cap"] != float64(8) {
+		t.Errorf("Topology describes a %v producer with an input of %v, expected lru and 8", topology["kind"], topology["input_cap"])
+	}
+	if stats, _ := topology["stats"].(map[string]any); stats["delivered"] != float64(1) {
+		t.Errorf("Topology stats are %v, expected 1 delivered item", topology["stats"])
+	}
+
+	consumers, _ := topology["consumers"].([]any)
+	if len(consumers) != 2 {
+		t.Fatalf("Topology lists %d consumers, expected 2", len(consumers))
+	}
+	first, _ := consumers[0].(map[string]any)
+	for _, field := range []string{"id", "name", "tags", "created_at", "last_used", "buffer_len", "buffer_cap", "high_water", "received"} {
+		if _, ok := first[field]; !ok {
+			t.Errorf("Consumer has no %s field: %v", field, first)
+		}
+	}
+	second, _ := consumers[1].(map[string]any)
+	if first["id"] != named.Id() || first["name"] != "audit" || first["received"] != float64(1) {
+		t.Errorf("First consumer is %v, expected the named consumer with 1 item", first)
+	}
+	if tags, _ := second["tags"].(map[string]any); second["id"] != tagged.Id() || tags["region"] != "eu" {
+		t.Errorf("Second consumer is %v, expected the tagged consumer", second)
+	}
+
+	// The snapshot's tags are a copy
+	fanout.ConsumerInfo()[1].Tags["region"] = "us"
+	if region := fanout.ConsumerInfo()[1].Tags["region"]; region != "eu" {
+		t.Errorf("Changing a snapshot changed the consumer's region to %s", region)
+	}
+}
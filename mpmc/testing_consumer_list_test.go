@@ -0,0 +1,25 @@
+package mpmc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConsumerListMinMaxBy(t *testing.T) {
+	now := time.Now()
+	older := &Consumer[int]{id: "older", weight: 2, lastUsed: now.Add(-time.Second)}
+	newer := &Consumer[int]{id: "newer", weight: 2, lastUsed: now}
+	light := &Consumer[int]{id: "light", weight: 1, lastUsed: now}
+	consumers := ConsumerList[int]{newer, light, older}
+	weight := func(c *Consumer[int]) float64 { return float64(c.weight) }
+
+	if selected := consumers.MaxBy(weight); selected != older {
+		t.Errorf("MaxBy selected %s, expected the least recently used of the heaviest, older", selected.id)
+	}
+	if selected := consumers.MinBy(weight); selected != light {
+		t.Errorf("MinBy selected %s, expected light", selected.id)
+	}
+	if selected := (ConsumerList[int]{}).MinBy(weight); selected != nil {
+		t.Errorf("MinBy selected %s from an empty list", selected.id)
+	}
+}
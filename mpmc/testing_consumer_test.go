@@ -0,0 +1,349 @@
+package mpmc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gompmc/logger"
+)
+
+func TestDrainBuffered(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	if items := consumer.DrainBuffered(); len(items) != 0 {
+		t.Errorf("DrainBuffered returned %v on an empty buffer", items)
+	}
+
+	for i := 0; i < 5; i++ {
+		fanout.Write(i)
+	}
+	for len(consumer.Messages) < 5 {
+		select {
+		case <-time.After(time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("Consumer did not receive all items")
+		}
+	}
+
+	items := consumer.DrainBuffered()
+	if !equalSlices(items, []int{0, 1, 2, 3, 4}) {
+		t.Errorf("DrainBuffered returned %v, expected [0 1 2 3 4]", items)
+	}
+}
+
+func TestSendWithoutTraceDoesNotAllocate(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 1, 1, WithLogLevel[int](logger.LogLevelDebug))
+	defer fanout.Close()
+
+	consumer := newConsumer(fanout, context.Background(), 1)
+	allocs := testing.AllocsPerRun(100, func() {
+		fanout.send(consumer, 1)
+		<-consumer.Messages
+	})
+	if allocs != 0 {
+		t.Errorf("send allocated %.1f times per item with tracing disabled", allocs)
+	}
+}
+
+func TestRunPool(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 64, 64)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	for i := 0; i < 50; i++ {
+		fanout.Write(i)
+	}
+
+	var mu sync.Mutex
+	processed := 0
+	failure := errors.New("failure")
+	err := consumer.RunPool(ctx, 4, func(item int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		processed++
+		if processed == 20 {
+			return failure
+		}
+		return nil
+	})
+
+	if err != failure {
+		t.Errorf("RunPool returned %v, expected %v", err, failure)
+	}
+	if processed < 20 {
+		t.Errorf("RunPool processed %d items, expected at least 20", processed)
+	}
+
+	poolCtx, poolCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer poolCancel()
+	if err := consumer.RunPool(poolCtx, 4, func(int) error { return nil }); err != context.DeadlineExceeded {
+		t.Errorf("RunPool returned %v, expected %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestBufferHighWater(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	consumer := fanout.CreateConsumer(context.Background())
+	for i := 0; i < 5; i++ {
+		if _, err := fanout.WriteTracked(i); err != nil {
+			t.Fatalf("WriteTracked returned %v", err)
+		}
+	}
+	consumer.DrainBuffered()
+	fanout.WriteTracked(5)
+
+	if consumer.BufferHighWater() != 5 {
+		t.Errorf("BufferHighWater is %d, expected 5", consumer.BufferHighWater())
+	}
+
+	consumer.ResetHighWater()
+	if consumer.BufferHighWater() != 0 {
+		t.Errorf("BufferHighWater is %d after reset, expected 0", consumer.BufferHighWater())
+	}
+
+	fanout.WriteTracked(6)
+	if consumer.BufferHighWater() != 2 {
+		t.Errorf("BufferHighWater is %d, expected 2", consumer.BufferHighWater())
+	}
+}
+
+func TestConsumerStatsAfterClose(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	consumer := fanout.CreateConsumer(context.Background())
+	for i := 0; i < 3; i++ {
+		fanout.WriteTracked(i)
+	}
+
+	consumer.Close()
+	for len(fanout.ConsumerInfo()) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	stats := consumer.Stats()
+	if stats.Received != 3 {
+		t.Errorf("Consumer received %d items after close, expected 3", stats.Received)
+	}
+	if stats.LastSequence != 3 {
+		t.Errorf("Consumer last sequence is %d after close, expected 3", stats.LastSequence)
+	}
+}
+
+func TestRunBulk(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 64, 64)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	for i := 0; i < 10; i++ {
+		fanout.WriteTracked(i)
+	}
+	// Closed with items still buffered, which are handled as final batches
+	consumer.Close()
+
+	var received []int
+	err := consumer.RunBulk(ctx, 4, func(batch []int) error {
+		if len(batch) == 0 || len(batch) > 4 {
+			t.Errorf("Batch has %d items, expected 1 to 4", len(batch))
+		}
+		received = append(received, batch...)
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("RunBulk returned %v, expected nil", err)
+	}
+	if !equalSlices(received, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Errorf("RunBulk received %v", received)
+	}
+
+	failure := errors.New("failure")
+	other := fanout.CreateConsumer(ctx)
+	fanout.WriteTracked(10)
+	if err := other.RunBulk(ctx, 4, func([]int) error { return failure }); err != failure {
+		t.Errorf("RunBulk returned %v, expected %v", err, failure)
+	}
+}
+
+func TestCloseAndDrain(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 64, 64)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	for i := 0; i < 5; i++ {
+		fanout.WriteTracked(i)
+	}
+
+	// Nobody reads, so the drain times out with every item left
+	drainCtx, drainCancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer drainCancel()
+	if remaining := consumer.CloseAndDrain(drainCtx); remaining != 5 {
+		t.Errorf("CloseAndDrain left %d items, expected 5", remaining)
+	}
+	if len(fanout.ConsumerInfo()) != 0 {
+		t.Error("Consumer is still attached after CloseAndDrain")
+	}
+
+	consumer = fanout.CreateConsumer(ctx)
+	for i := 0; i < 5; i++ {
+		fanout.WriteTracked(i)
+	}
+	go func() {
+		for {
+			select {
+			case <-consumer.Messages:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	if remaining := consumer.CloseAndDrain(ctx); remaining != 0 {
+		t.Errorf("CloseAndDrain left %d items with an active reader, expected 0", remaining)
+	}
+}
+
+func TestReceiveMatching(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	for i := 1; i <= 5; i++ {
+		fanout.WriteTracked(i)
+	}
+
+	item, err := consumer.ReceiveMatching(ctx, func(item int) bool { return item == 3 })
+	if err != nil || item != 3 {
+		t.Errorf("ReceiveMatching returned %d, %v, expected 3", item, err)
+	}
+	// The skipped items are gone, the later ones are still buffered
+	if remaining := consumer.DrainBuffered(); !equalSlices(remaining, []int{4, 5}) {
+		t.Errorf("Buffer holds %v after ReceiveMatching, expected [4 5]", remaining)
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer waitCancel()
+	if _, err := consumer.ReceiveMatching(waitCtx, func(int) bool { return true }); err != context.DeadlineExceeded {
+		t.Errorf("ReceiveMatching returned %v, expected %v", err, context.DeadlineExceeded)
+	}
+
+	consumer.Close()
+	if _, err := consumer.ReceiveMatching(ctx, func(int) bool { return true }); err != ErrConsumerClosed {
+		t.Errorf("ReceiveMatching returned %v, expected %v", err, ErrConsumerClosed)
+	}
+}
+
+func TestWaitEmpty(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	if err := consumer.WaitEmpty(ctx); err != nil {
+		t.Errorf("WaitEmpty returned %v for an empty consumer", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		fanout.WriteTracked(i)
+	}
+	waitCtx, waitCancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer waitCancel()
+	if err := consumer.WaitEmpty(waitCtx); err != context.DeadlineExceeded {
+		t.Errorf("WaitEmpty returned %v without a reader, expected %v", err, context.DeadlineExceeded)
+	}
+
+	go func() {
+		for i := 0; i < 5; i++ {
+			<-consumer.Messages
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	if err := consumer.WaitEmpty(ctx); err != nil {
+		t.Errorf("WaitEmpty returned %v with a reader, expected nil", err)
+	}
+}
+
+func TestMessagesIsReceiveOnly(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_Single, 1, 1)
+	defer fanout.Close()
+
+	// close(consumer.Messages) and consumer.Messages <- 1 do not compile
+	consumer := fanout.CreateConsumer(context.Background())
+	var messages any = consumer.Messages
+	if _, ok := messages.(chan int); ok {
+		t.Error("Messages is a bidirectional channel that users can close")
+	}
+	if _, ok := messages.(<-chan int); !ok {
+		t.Errorf("Messages is a %T, expected <-chan int", messages)
+	}
+}
+
+func TestConsumerRemovalLogsCause(t *testing.T) {
+	var buf syncBuffer
+	fanout := NewProducer[int](ProducerKind_Single, 1, 1,
+		WithLogLevel[int](logger.LogLevelDebug),
+		WithSlog[int](slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	consumer := fanout.CreateConsumer(ctx)
+	plain := fanout.CreateConsumer(context.Background())
+	cancel(errors.New("client went away"))
+	plain.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for len(fanout.ConsumerInfo()) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	logs := buf.String()
+	if !strings.Contains(logs, "Consumer "+consumer.Id()+" closed, cause: client went away") {
+		t.Errorf("Removal log does not include the cancellation cause:\n%s", logs)
+	}
+	if !strings.Contains(logs, "Consumer "+plain.Id()+" closed, removing") {
+		t.Errorf("Removal log of a plain cancel is missing:\n%s", logs)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes from the Producer's goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,63 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDropCounts(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 1, 1, WithInputFilter(func(item int) bool { return item >= 0 }))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	fanout.WriteTracked(0)
+	fanout.WriteTracked(-1)
+	fanout.WriteRouted("missing", 0)
+
+	fanout.CreateConsumer(ctx)
+	fanout.CreateConsumer(ctx)
+	fanout.WriteTracked(1)
+	// Both consumer buffers are full now
+	fanout.WriteTracked(2)
+
+	// Paused, so the second write finds the input buffer full
+	fanout.Pause()
+	fanout.Write(3)
+	fanout.Write(4)
+
+	expected := map[DropReason]uint64{
+		DropInputFull:      1,
+		DropCallerLimit:    0,
+		DropNoConsumers:    1,
+		DropConsumerFull:   2,
+		DropUnknownRoute:   1,
+		DropFiltered:       1,
+		DropRetryOverflow:  0,
+		DropClosed:         0,
+		DropBudgetExceeded: 0,
+		DropInvalid:        0,
+	}
+	counts := fanout.DropCounts()
+	if len(counts) != len(expected) {
+		t.Errorf("DropCounts has %d reasons, expected %d", len(counts), len(expected))
+	}
+	for reason, count := range expected {
+		if counts[reason] != count {
+			t.Errorf("DropCounts[%v] is %d, expected %d", reason, counts[reason], count)
+		}
+	}
+	stats := fanout.StatsAndReset()
+	if stats.DroppedInputFull != 1 || stats.DroppedConsumerFull != 2 || stats.DroppedNoConsumers != 1 {
+		t.Errorf("Stats reports %d input full, %d consumer full and %d no consumers drops, expected 1, 2 and 1",
+			stats.DroppedInputFull, stats.DroppedConsumerFull, stats.DroppedNoConsumers)
+	}
+	if stats := fanout.Stats(); stats.DroppedInputFull != 0 || stats.DroppedConsumerFull != 0 || stats.DroppedNoConsumers != 0 {
+		t.Errorf("Drop counters were not reset: %+v", stats)
+	}
+	if counts := fanout.DropCounts(); counts[DropInputFull] != 0 || counts[DropUnknownRoute] != 0 {
+		t.Errorf("DropCounts were not reset: %v", counts)
+	}
+}
@@ -0,0 +1,82 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	fanout.WriteTracked(0)
+	consumer := fanout.CreateConsumer(ctx)
+	fanout.RemoveConsumer(consumer.Id())
+	fanout.ShutdownOrdered(ctx)
+
+	expected := []Event{
+		{Kind: EventDropped, DropReason: DropNoConsumers},
+		{Kind: EventConsumerAdded, ConsumerID: consumer.Id()},
+		{Kind: EventConsumerRemoved, ConsumerID: consumer.Id()},
+		{Kind: EventProducerClosed},
+	}
+	for _, want := range expected {
+		select {
+		case got := <-fanout.Events():
+			if got.Kind != want.Kind || got.ConsumerID != want.ConsumerID || got.DropReason != want.DropReason {
+				t.Errorf("Event is %v %q %v, expected %v %q %v",
+					got.Kind, got.ConsumerID, got.DropReason, want.Kind, want.ConsumerID, want.DropReason)
+			}
+			if got.Time.IsZero() {
+				t.Error("Event has no time")
+			}
+		case <-ctx.Done():
+			t.Fatalf("Missing %v event", want.Kind)
+		}
+	}
+}
+
+func TestEventsSlowConsumer(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 1)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	fanout.WriteTracked(0)
+	fanout.WriteTracked(1)
+
+	expected := []Event{
+		{Kind: EventConsumerAdded, ConsumerID: consumer.Id()},
+		{Kind: EventSlowConsumer, ConsumerID: consumer.Id()},
+		{Kind: EventDropped, DropReason: DropConsumerFull},
+	}
+	for _, want := range expected {
+		select {
+		case got := <-fanout.Events():
+			if got.Kind != want.Kind || got.ConsumerID != want.ConsumerID || got.DropReason != want.DropReason {
+				t.Errorf("Event is %v %q %v, expected %v %q %v",
+					got.Kind, got.ConsumerID, got.DropReason, want.Kind, want.ConsumerID, want.DropReason)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Missing %v event", want.Kind)
+		}
+	}
+}
+
+func TestEventsBufferFull(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16, WithEventBuffer[int](1))
+	defer fanout.Close()
+
+	// Nobody reads, so every event after the first is discarded without blocking dispatch
+	for i := 0; i < 3; i++ {
+		fanout.WriteTracked(i)
+	}
+	if len(fanout.Events()) != 1 {
+		t.Errorf("Event stream holds %d events, expected 1", len(fanout.Events()))
+	}
+}
@@ -0,0 +1,56 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFailOnDrop(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 1, WithFailOnDrop[int]())
+	defer fanout.Close()
+
+	if err := fanout.Err(); err != nil {
+		t.Fatalf("Err is %v before any drop", err)
+	}
+
+	// No consumers yet, so the first item is dropped
+	if _, err := fanout.WriteTracked(1); err != nil {
+		t.Fatalf("WriteTracked returned %v", err)
+	}
+	if err := fanout.Err(); !errors.Is(err, ErrDropped) {
+		t.Fatalf("Err is %v, expected ErrDropped", err)
+	}
+	if err := fanout.Write(2); !errors.Is(err, ErrDropped) {
+		t.Errorf("Write returned %v, expected the sticky error", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	consumer := fanout.CreateConsumer(ctx)
+
+	fanout.Reset()
+	if err := fanout.Err(); err != nil {
+		t.Fatalf("Err is %v after Reset", err)
+	}
+	if id, err := fanout.WriteTracked(3); err != nil || id != consumer.Id() {
+		t.Errorf("WriteTracked returned %q, %v after Reset", id, err)
+	}
+	if err := fanout.Err(); err != nil {
+		t.Errorf("Err is %v after a delivered item", err)
+	}
+}
+
+func TestFailOnDropDisabled(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 1)
+	defer fanout.Close()
+
+	fanout.WriteTracked(1)
+	if err := fanout.Err(); err != nil {
+		t.Errorf("Err is %v without WithFailOnDrop", err)
+	}
+	if err := fanout.Write(2); err != nil {
+		t.Errorf("Write returned %v without WithFailOnDrop", err)
+	}
+}
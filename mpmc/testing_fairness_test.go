@@ -0,0 +1,40 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFairnessWindow(t *testing.T) {
+	const window, consumers, items = 10, 3, 3000
+	fanout := NewProducer[int](ProducerKind_Single, 16, items, WithFairnessWindow[int](window))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < consumers; i++ {
+		fanout.CreateConsumer(ctx)
+	}
+
+	delivered := make([]string, 0, items)
+	for i := 0; i < items; i++ {
+		id, err := fanout.WriteTracked(i)
+		if err != nil || id == "" {
+			t.Fatalf("WriteTracked returned %q, %v", id, err)
+		}
+		delivered = append(delivered, id)
+	}
+
+	share := (window + consumers - 1) / consumers
+	counts := map[string]int{}
+	for i, id := range delivered {
+		counts[id]++
+		if i >= window {
+			counts[delivered[i-window]]--
+		}
+		if counts[id] > share {
+			t.Fatalf("Consumer %s received %d of the %d deliveries ending at %d, expected at most %d", id, counts[id], window, i, share)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFallback(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16, WithFallback[int](ProducerKind_All))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	// A slow consumer that can only ever hold two items
+	slow := make(chan int, 2)
+	fanout.AddOutputChannel(slow)
+	fast := fanout.CreateConsumer(ctx)
+
+	if err := fanout.WaitForConsumers(ctx, 2); err != nil {
+		t.Fatalf("WaitForConsumers returned %v", err)
+	}
+
+	numItems := 10
+	for i := 0; i < numItems; i++ {
+		if err := fanout.Write(i); err != nil {
+			t.Fatalf("Write returned %v", err)
+		}
+	}
+
+	received := 0
+	for received < numItems-cap(slow) {
+		select {
+		case <-fast.Messages:
+			received++
+		case <-ctx.Done():
+			t.Fatalf("Fast consumer received %d items, expected %d", received, numItems-cap(slow))
+		}
+	}
+
+	if len(slow) != cap(slow) {
+		t.Errorf("Slow consumer holds %d items, expected %d", len(slow), cap(slow))
+	}
+}
+
+func TestFallbackAfterAllCountsDropsOnce(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 1, WithFallback[int](ProducerKind_LRU))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	fanout.CreateConsumer(ctx)
+	fanout.CreateConsumer(ctx)
+	fanout.WriteTracked(0)
+	// Both buffers are full, so All and the LRU fallback both fail
+	fanout.WriteTracked(1)
+
+	if dropped := fanout.DropCounts()[DropConsumerFull]; dropped != 2 {
+		t.Errorf("DropConsumerFull is %d, expected 2, one per consumer that missed the item", dropped)
+	}
+}
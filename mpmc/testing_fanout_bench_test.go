@@ -0,0 +1,122 @@
+package mpmc
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gompmc/logger"
+)
+
+func BenchmarkFanoutAll(b *testing.B) {
+	for _, batch := range []int{1, DefaultDispatchBatch} {
+		b.Run(fmt.Sprintf("batch=%d", batch), func(b *testing.B) {
+			benchmarkFanoutAll(b, WithDispatchBatch[int](batch))
+		})
+	}
+}
+
+func benchmarkFanoutAll(b *testing.B, options ...Option[int]) {
+	options = append(options, WithLogLevel[int](logger.LogLevelError))
+	fanout := NewProducer[int](ProducerKind_All, 1024, 1024, options...)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	numConsumers := 4
+	var wg sync.WaitGroup
+	for i := 0; i < numConsumers; i++ {
+		consumer := fanout.CreateConsumer(ctx)
+		wg.Add(1)
+		go func(c *Consumer[int]) {
+			defer wg.Done()
+			for {
+				select {
+				case <-c.Messages:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(consumer)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fanout.input <- envelope[int]{item: i}
+	}
+	// Every item taken from the input is numbered, delivered or not
+	for fanout.Sequence() < int64(b.N) {
+		runtime.Gosched()
+	}
+	b.StopTimer()
+
+	cancel()
+	wg.Wait()
+}
+
+func BenchmarkWriteTrackedAll(b *testing.B) {
+	fanout := NewProducer[int](ProducerKind_All, 1024, 1024, WithLogLevel[int](logger.LogLevelError))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	go func() {
+		for {
+			select {
+			case <-consumer.Messages:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fanout.WriteTracked(i)
+	}
+}
+
+func BenchmarkSelectLRU(b *testing.B) {
+	for _, n := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("consumers=%d/sort", n), func(b *testing.B) {
+			benchmarkSelectLRU(b, n, func(consumers ConsumerList[int]) *Consumer[int] {
+				sort.Sort(consumers)
+				return consumers[0]
+			})
+		})
+		b.Run(fmt.Sprintf("consumers=%d/scan", n), func(b *testing.B) {
+			benchmarkSelectLRU(b, n, ConsumerList[int].leastRecentlyUsed)
+		})
+	}
+}
+
+// benchmarkSelectLRU measures an LRU selection over n consumers, marking the selected consumer
+// as just used after each pick like dispatch does.
+func benchmarkSelectLRU(b *testing.B, n int, selectLRU func(ConsumerList[int]) *Consumer[int]) {
+	fanout := NewProducer[int](ProducerKind_LRU, 1, 1, WithLogLevel[int](logger.LogLevelError))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	base := time.Now()
+	consumers := make(ConsumerList[int], n)
+	for i := range consumers {
+		consumers[i] = newConsumer(fanout, ctx, 1)
+		consumers[i].lastUsed = base.Add(time.Duration(rand.Intn(n)) * time.Millisecond)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		selectLRU(consumers).lastUsed = base.Add(time.Duration(n+i) * time.Millisecond)
+	}
+}
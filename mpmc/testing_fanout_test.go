@@ -20,26 +20,16 @@ func TestFanoutAll(t *testing.T) {
 	defer cancel()
 
 	var wg sync.WaitGroup
-	results := make([][]int, numConsumers)
+	consumers := make([]*Consumer[int], numConsumers)
 
-	// Create consumers
-	for i := 0; i < numConsumers; i++ {
-		consumer := fanout.CreateConsumer(ctx)
-		results[i] = make([]int, 0, totalItems)
-
-		wg.Add(1)
-		go func(c *Consumer[int], resultSlice *[]int) {
-			defer wg.Done()
-			for {
-				select {
-				case item := <-c.Messages:
-					*resultSlice = append(*resultSlice, item)
-				case <-ctx.Done():
-					return
-				}
-			}
-		}(consumer, &results[i])
+	// Create consumers, read while the producers write
+	for i := range consumers {
+		consumers[i] = fanout.CreateConsumer(ctx)
 	}
+	collected := make(chan [][]int, 1)
+	go func() {
+		collected <- CollectAll(consumers, 100*time.Millisecond)
+	}()
 
 	// Create producers
 	for i := 0; i < numProducers; i++ {
@@ -48,7 +38,7 @@ func TestFanoutAll(t *testing.T) {
 			defer wg.Done()
 			for j := 0; j < itemsPerProducer; j++ {
 				select {
-				case fanout.input <- producerID*itemsPerProducer + j:
+				case fanout.input <- envelope[int]{item: producerID*itemsPerProducer + j}:
 				case <-ctx.Done():
 					return
 				}
@@ -57,6 +47,7 @@ func TestFanoutAll(t *testing.T) {
 	}
 
 	wg.Wait()
+	results := <-collected
 
 	// Verify results
 	for i, result := range results {
@@ -86,26 +77,16 @@ func TestFanoutSingle(t *testing.T) {
 	defer cancel()
 
 	var wg sync.WaitGroup
-	results := make([][]int, numConsumers)
-
-	// Create consumers
-	for i := 0; i < numConsumers; i++ {
-		consumer := fanout.CreateConsumer(ctx)
-		results[i] = make([]int, 0, totalItems/numConsumers)
+	consumers := make([]*Consumer[int], numConsumers)
 
-		wg.Add(1)
-		go func(c *Consumer[int], resultSlice *[]int) {
-			defer wg.Done()
-			for {
-				select {
-				case item := <-c.Messages:
-					*resultSlice = append(*resultSlice, item)
-				case <-ctx.Done():
-					return
-				}
-			}
-		}(consumer, &results[i])
+	// Create consumers, read while the producers write
+	for i := range consumers {
+		consumers[i] = fanout.CreateConsumer(ctx)
 	}
+	collected := make(chan [][]int, 1)
+	go func() {
+		collected <- CollectAll(consumers, 100*time.Millisecond)
+	}()
 
 	// Create producers
 	tp := time.Now()
@@ -116,7 +97,7 @@ func TestFanoutSingle(t *testing.T) {
 			t.Logf("Producer %d started %v", producerID, time.Since(tp))
 			for j := 0; j < itemsPerProducer; j++ {
 				select {
-				case fanout.input <- producerID*itemsPerProducer + j:
+				case fanout.input <- envelope[int]{item: producerID*itemsPerProducer + j}:
 				case <-ctx.Done():
 					return
 				}
@@ -126,6 +107,7 @@ func TestFanoutSingle(t *testing.T) {
 	}
 
 	wg.Wait()
+	results := <-collected
 	t.Logf("Producer Time taken: %v", time.Since(tp))
 
 	// Verify results
@@ -161,26 +143,16 @@ func TestFanoutLRU(t *testing.T) {
 	defer cancel()
 
 	var wg sync.WaitGroup
-	results := make([][]int, numConsumers)
-
-	// Create consumers
-	for i := 0; i < numConsumers; i++ {
-		consumer := fanout.CreateConsumer(ctx)
-		results[i] = make([]int, 0, totalItems/numConsumers)
+	consumers := make([]*Consumer[int], numConsumers)
 
-		wg.Add(1)
-		go func(c *Consumer[int], resultSlice *[]int) {
-			defer wg.Done()
-			for {
-				select {
-				case item := <-c.Messages:
-					*resultSlice = append(*resultSlice, item)
-				case <-ctx.Done():
-					return
-				}
-			}
-		}(consumer, &results[i])
+	// Create consumers, read while the producers write
+	for i := range consumers {
+		consumers[i] = fanout.CreateConsumer(ctx)
 	}
+	collected := make(chan [][]int, 1)
+	go func() {
+		collected <- CollectAll(consumers, 100*time.Millisecond)
+	}()
 
 	// Create producers
 	tp := time.Now()
@@ -191,7 +163,7 @@ func TestFanoutLRU(t *testing.T) {
 			t.Logf("Producer %d started %v", producerID, time.Since(tp))
 			for j := 0; j < itemsPerProducer; j++ {
 				select {
-				case fanout.input <- producerID*itemsPerProducer + j:
+				case fanout.input <- envelope[int]{item: producerID*itemsPerProducer + j}:
 				case <-ctx.Done():
 					return
 				}
@@ -201,6 +173,7 @@ func TestFanoutLRU(t *testing.T) {
 	}
 
 	wg.Wait()
+	results := <-collected
 	t.Logf("Producer Time taken: %v", time.Since(tp))
 
 	// Verify results
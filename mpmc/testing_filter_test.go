@@ -0,0 +1,35 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInputFilter(t *testing.T) {
+	even := func(item int) bool { return item%2 == 0 }
+	fanout := NewProducer[int](ProducerKind_All, 16, 16, WithInputFilter(even), WithFailOnDrop[int]())
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumers := []*Consumer[int]{fanout.CreateConsumer(ctx), fanout.CreateConsumer(ctx)}
+	for i := 0; i < 10; i++ {
+		if _, err := fanout.WriteTracked(i); err != nil {
+			t.Fatalf("WriteTracked returned %v", err)
+		}
+	}
+
+	for _, consumer := range consumers {
+		if received := consumer.DrainBuffered(); !equalSlices(received, []int{0, 2, 4, 6, 8}) {
+			t.Errorf("Consumer received %v, expected only even items", received)
+		}
+	}
+	if filtered := fanout.Stats().Filtered; filtered != 5 {
+		t.Errorf("Filtered is %d, expected 5", filtered)
+	}
+	if err := fanout.Err(); err != nil {
+		t.Errorf("Filtered items were counted as drops: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package mpmc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// keyOwner writes a keyed item and returns the ID of the group member that received it.
+func keyOwner(fanout *Producer[int], group, key string) string {
+	tracked := make(chan dispatchResult, 1)
+	if err := fanout.write(envelope[int]{group: group, key: key, tracked: tracked}); err != nil {
+		return ""
+	}
+	return (<-tracked).consumerID
+}
+
+func TestConsumerGroup(t *testing.T) {
+	var rebalances [][]string
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 256, WithOnRebalance[int](func(group string, members []string) {
+		if group != "workers" {
+			t.Errorf("Rebalance of group %q, expected workers", group)
+		}
+		rebalances = append(rebalances, members)
+	}))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	if err := fanout.WriteKeyed("workers", "a", 0); err != ErrNoGroupMembers {
+		t.Errorf("WriteKeyed returned %v for an empty group, expected ErrNoGroupMembers", err)
+	}
+
+	fanout.JoinGroup(ctx, "workers")
+	fanout.JoinGroup(ctx, "workers")
+	leaving := fanout.JoinGroup(ctx, "workers")
+	if len(rebalances) != 3 || len(rebalances[2]) != 3 {
+		t.Fatalf("Rebalances were %v, expected one per join", rebalances)
+	}
+
+	before := map[string]string{}
+	owners := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprint("key", i)
+		before[key] = keyOwner(fanout, "workers", key)
+		owners[before[key]] = true
+		if again := keyOwner(fanout, "workers", key); again != before[key] {
+			t.Errorf("Key %s moved from %s to %s without a membership change", key, before[key], again)
+		}
+	}
+	if len(owners) != 3 {
+		t.Errorf("Keys were spread over %d members, expected 3", len(owners))
+	}
+
+	// Only the keys of the member that leaves move
+	fanout.RemoveConsumer(leaving.Id())
+	if last := rebalances[len(rebalances)-1]; len(last) != 2 {
+		t.Errorf("Rebalance after leaving reported %v, expected 2 members", last)
+	}
+	for key, owner := range before {
+		after := keyOwner(fanout, "workers", key)
+		if owner != leaving.Id() && after != owner {
+			t.Errorf("Key %s moved from %s to %s although its owner stayed", key, owner, after)
+		}
+		if after == leaving.Id() {
+			t.Errorf("Key %s went to the member that left", key)
+		}
+	}
+}
+
+func TestConsumerGroupHashFunc(t *testing.T) {
+	// Keys hash by their first letter, so "apple" and "avocado" collide
+	firstLetter := func(key string) uint64 { return DefaultHash(key[:1]) }
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 256, WithHashFunc[int](firstLetter))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for i := 0; i < 4; i++ {
+		fanout.JoinGroup(ctx, "workers")
+	}
+
+	owners := map[string]bool{}
+	for _, key := range []string{"apple", "avocado", "apricot", "almond"} {
+		owners[keyOwner(fanout, "workers", key)] = true
+	}
+	if len(owners) != 1 {
+		t.Errorf("Colliding keys went to %d members, expected 1", len(owners))
+	}
+}
+
+func TestConsumerGroupHashFuncModulo(t *testing.T) {
+	// Keys are partition numbers, as assigned by another system with n partitions
+	partition := func(key string) uint64 {
+		n, _ := strconv.ParseUint(key, 10, 64)
+		return n
+	}
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 256, WithHashFunc[int](partition))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	members := make([]*Consumer[int], 3)
+	for i := range members {
+		members[i] = fanout.JoinGroup(ctx, "workers")
+	}
+	// The LRU strategy reorders the consumer list, which must not change ownership
+	for i := 0; i < 5; i++ {
+		fanout.WriteTracked(i)
+	}
+
+	for key := 0; key < 9; key++ {
+		if owner, expected := keyOwner(fanout, "workers", strconv.Itoa(key)), members[key%3].Id(); owner != expected {
+			t.Errorf("Key %d went to %s, expected member %d %s", key, owner, key%3, expected)
+		}
+	}
+}
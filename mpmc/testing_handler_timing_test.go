@@ -0,0 +1,51 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHandlerTiming(t *testing.T) {
+	done := errors.New("done")
+	run := func(options ...Option[int]) ConsumerStats {
+		fanout := NewProducer[int](ProducerKind_All, 16, 16, options...)
+		defer fanout.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+		defer cancel()
+
+		consumer := fanout.CreateConsumer(ctx)
+		for i := 1; i <= 3; i++ {
+			fanout.WriteTracked(i)
+		}
+		handled := 0
+		err := consumer.RunPool(ctx, 1, func(item int) error {
+			time.Sleep(time.Duration(item) * 5 * time.Millisecond)
+			if handled++; handled == 3 {
+				return done
+			}
+			return nil
+		})
+		if err != done {
+			t.Fatalf("RunPool returned %v, expected %v", err, done)
+		}
+		return consumer.Stats()
+	}
+
+	stats := run()
+	if stats.HandlerCalls != 3 {
+		t.Errorf("Expected 3 handler calls, got %d", stats.HandlerCalls)
+	}
+	if stats.HandlerMax < 15*time.Millisecond {
+		t.Errorf("Expected the longest handler call to take at least 15ms, got %v", stats.HandlerMax)
+	}
+	if stats.HandlerAvg < 10*time.Millisecond || stats.HandlerAvg > stats.HandlerMax {
+		t.Errorf("Expected the average handler call to take between 10ms and %v, got %v", stats.HandlerMax, stats.HandlerAvg)
+	}
+
+	if stats := run(WithoutHandlerTiming[int]()); stats.HandlerCalls != 0 || stats.HandlerAvg != 0 || stats.HandlerMax != 0 {
+		t.Errorf("Expected no handler stats with timing disabled, got %+v", stats)
+	}
+}
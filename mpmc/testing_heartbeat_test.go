@@ -0,0 +1,60 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16, WithHeartbeat(20*time.Millisecond, func() int { return -1 }))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+
+	select {
+	case item := <-consumer.Messages:
+		if item != -1 {
+			t.Errorf("Idle consumer received %d, expected heartbeat -1", item)
+		}
+	case <-ctx.Done():
+		t.Fatal("Idle consumer did not receive a heartbeat")
+	}
+
+	if stats := consumer.Stats(); stats.Received != 0 {
+		t.Errorf("Heartbeat counted as %d received items, expected 0", stats.Received)
+	}
+}
+
+func TestHeartbeatNeverEvicts(t *testing.T) {
+	// Heartbeats outrank every item, so a full priority buffer would evict an item for one
+	fanout := NewProducer[int](ProducerKind_All, 16, 2,
+		WithHeartbeat(30*time.Millisecond, func() int { return 100 }),
+		WithPriorityConsumerBuffers(func(item int) int { return item }))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	// One item is held by the Messages channel, two fill the buffer well before the first heartbeat
+	fanout.WriteTracked(0)
+	for fanout.ConsumerInfo()[0].BufferLen != 0 && ctx.Err() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	fanout.WriteTracked(1)
+	fanout.WriteTracked(2)
+	time.Sleep(100 * time.Millisecond)
+
+	if dropped := fanout.DropCounts()[DropConsumerFull]; dropped != 0 {
+		t.Errorf("Heartbeats evicted %d items from the full buffer, expected 0", dropped)
+	}
+	for i := 0; i < 3; i++ {
+		if item := <-consumer.Messages; item == 100 {
+			t.Fatalf("Heartbeat %d delivered in place of a buffered item", i)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIdleTimeout(t *testing.T) {
+	idleClosed := make(chan struct{})
+	fanout := NewProducer[int](ProducerKind_All, 16, 16,
+		WithIdleTimeout[int](50*time.Millisecond),
+		WithOnIdleClose[int](func() { close(idleClosed) }))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	consumer := fanout.CreateConsumer(ctx)
+
+	// Writes keep the Producer open past the timeout
+	for i := 0; i < 4; i++ {
+		if err := fanout.Write(i); err != nil {
+			t.Fatalf("Write returned %v while active", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	select {
+	case <-idleClosed:
+	case <-ctx.Done():
+		t.Fatal("Producer did not close after the idle timeout")
+	}
+
+	if err := fanout.Write(4); err != ErrProducerClosed {
+		t.Errorf("Write returned %v after the idle close, expected ErrProducerClosed", err)
+	}
+	select {
+	case <-consumer.ctx.Done():
+	case <-ctx.Done():
+		t.Error("Consumer was not closed with the Producer")
+	}
+}
@@ -0,0 +1,59 @@
+//go:build go1.23
+
+package mpmc
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestConsumerAll(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	for i := 0; i < 5; i++ {
+		fanout.WriteTracked(i)
+	}
+
+	var got []int
+	for item := range consumer.All(ctx) {
+		got = append(got, item)
+		if len(got) == 3 {
+			break
+		}
+	}
+	if expected := []int{0, 1, 2}; !slices.Equal(got, expected) {
+		t.Errorf("Iteration yielded %v before break, expected %v", got, expected)
+	}
+
+	// Consumer close ends the iteration
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		consumer.Close()
+	}()
+	got = got[:0]
+	for item := range consumer.All(ctx) {
+		got = append(got, item)
+	}
+	if expected := []int{3, 4}; !slices.Equal(got, expected) {
+		t.Errorf("Iteration yielded %v before the Consumer closed, expected %v", got, expected)
+	}
+
+	// Context cancellation ends the iteration
+	other := fanout.CreateConsumer(context.Background())
+	defer other.Close()
+	short, stop := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer stop()
+	for item := range other.All(short) {
+		t.Errorf("Iteration yielded %v, expected nothing", item)
+	}
+	if ctx.Err() != nil {
+		t.Error("Iteration did not stop when its context was cancelled")
+	}
+}
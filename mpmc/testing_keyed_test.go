@@ -0,0 +1,117 @@
+package mpmc
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyedConsumerSerialPerKey(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 64, 64)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var active [3]atomic.Int32
+	last := map[int]int{}
+	fanout.KeyedConsumer(ctx, func(item int) string { return strconv.Itoa(item % 3) }, 3, time.Second, func(item int) {
+		defer wg.Done()
+		if active[item%3].Add(1) != 1 {
+			t.Errorf("Item %d handled concurrently with another item of its key", item)
+		}
+		time.Sleep(100 * time.Microsecond)
+		active[item%3].Add(-1)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if previous, ok := last[item%3]; ok && previous > item {
+			t.Errorf("Item %d handled after %d", item, previous)
+		}
+		last[item%3] = item
+	})
+
+	for i := 0; i < 60; i++ {
+		wg.Add(1)
+		if _, err := fanout.WriteTracked(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestKeyedConsumerLimitAndIdle(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	release := make(chan struct{})
+	handled := make(chan int, 2)
+	fanout.KeyedConsumer(ctx, func(item int) string { return strconv.Itoa(item) }, 1, 10*time.Millisecond, func(item int) {
+		if item == 0 {
+			<-release
+		}
+		handled <- item
+	})
+	baseline := fanout.GoroutineCount()
+
+	fanout.Write(0)
+	fanout.Write(1)
+
+	// Key 1 waits for the only worker, busy with key 0
+	select {
+	case item := <-handled:
+		t.Fatalf("Item %d handled while the worker limit was reached", item)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	for _, expected := range []int{0, 1} {
+		if item := <-handled; item != expected {
+			t.Errorf("Handled %d, expected %d", item, expected)
+		}
+	}
+
+	// The idle worker stops
+	for fanout.GoroutineCount() != baseline {
+		if ctx.Err() != nil {
+			t.Fatalf("GoroutineCount is %d, expected %d once the worker is idle", fanout.GoroutineCount(), baseline)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestKeyedConsumerEvictsEmptyKey(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	handled := make(chan int, 2)
+	// Item 0 has the empty key; with a single worker it must be evicted for item 1's key
+	fanout.KeyedConsumer(ctx, func(item int) string {
+		if item == 0 {
+			return ""
+		}
+		return strconv.Itoa(item)
+	}, 1, time.Minute, func(item int) { handled <- item })
+
+	for i := 0; i < 2; i++ {
+		fanout.WriteTracked(i)
+		select {
+		case item := <-handled:
+			if item != i {
+				t.Errorf("Handled %d, expected %d", item, i)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Item %d was not handled, the worker for the empty key was not evicted", i)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package mpmc
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLatencyBuckets(t *testing.T) {
+	previous := time.Duration(-1)
+	for i := 0; i < latencyBuckets; i++ {
+		upper := latencyBucketMax(i)
+		if upper <= previous {
+			t.Fatalf("Bucket %d ends at %d, after bucket %d at %d", i, upper, i-1, previous)
+		}
+		if bucket := latencyBucket(previous + 1); bucket != i {
+			t.Fatalf("Duration %d is counted in bucket %d, expected %d", previous+1, bucket, i)
+		}
+		if bucket := latencyBucket(upper); bucket != i {
+			t.Fatalf("Duration %d is counted in bucket %d, expected %d", upper, bucket, i)
+		}
+		if lower := previous + 1; lower > 0 && float64(upper-lower) > float64(lower)/latencySubBuckets {
+			t.Fatalf("Bucket %d spans %d to %d, more than 1/%d", i, lower, upper, latencySubBuckets)
+		}
+		previous = upper
+	}
+	if previous != math.MaxInt64 {
+		t.Errorf("Last bucket ends at %d, expected math.MaxInt64", previous)
+	}
+}
+
+func TestLatencyQuantiles(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16, WithLatencyQuantiles[int]())
+	defer fanout.Close()
+
+	if quantiles := fanout.LatencyQuantiles(); len(quantiles) != 0 {
+		t.Errorf("LatencyQuantiles returned %v before any delivery", quantiles)
+	}
+
+	// 98 items at 1ms and 2 items at 100ms
+	for i := 0; i < 100; i++ {
+		latency := time.Millisecond
+		if i >= 98 {
+			latency = 100 * time.Millisecond
+		}
+		fanout.latency_histogram.record(latency)
+	}
+
+	quantiles := fanout.LatencyQuantiles()
+	for q, expected := range map[float64]time.Duration{0.5: time.Millisecond, 0.95: time.Millisecond, 0.99: 100 * time.Millisecond} {
+		if got := quantiles[q]; got < expected || got > expected+expected/latencySubBuckets {
+			t.Errorf("Quantile %v is %v, expected %v", q, got, expected)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	fanout.CreateConsumer(ctx)
+	fanout.WriteTracked(1)
+
+	if quantiles := fanout.LatencyQuantiles(); quantiles[0.5] != latencyBucketMax(latencyBucket(time.Millisecond)) {
+		t.Errorf("Median is %v after one fast delivery, expected it unchanged", quantiles[0.5])
+	}
+
+	disabled := NewProducer[int](ProducerKind_LRU, 1, 1)
+	defer disabled.Close()
+	if quantiles := disabled.LatencyQuantiles(); quantiles != nil {
+		t.Errorf("LatencyQuantiles returned %v without WithLatencyQuantiles", quantiles)
+	}
+}
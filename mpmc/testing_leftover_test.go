@@ -0,0 +1,59 @@
+package mpmc
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOnLeftover(t *testing.T) {
+	for _, manual := range []bool{false, true} {
+		var options []Option[int]
+		if manual {
+			options = append(options, WithManualConsumerRemoval[int]())
+		}
+		fanout := NewProducer[int](ProducerKind_All, 16, 16, options...)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+		defer cancel()
+
+		consumer := fanout.CreateConsumer(ctx)
+		for i := 0; i < 4; i++ {
+			fanout.WriteTracked(i)
+		}
+		<-consumer.Messages
+
+		var mu sync.Mutex
+		var leftovers []int
+		consumer.OnLeftover(func(item int) {
+			mu.Lock()
+			defer mu.Unlock()
+			leftovers = append(leftovers, item)
+		})
+		if manual {
+			fanout.RemoveConsumer(consumer.Id())
+		} else {
+			consumer.Close()
+		}
+
+		expected := []int{1, 2, 3}
+		for {
+			mu.Lock()
+			got := slices.Clone(leftovers)
+			mu.Unlock()
+			if slices.Equal(got, expected) {
+				break
+			}
+			if ctx.Err() != nil {
+				t.Fatalf("OnLeftover received %v with manual removal %v, expected %v", got, manual, expected)
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if remaining := consumer.DrainBuffered(); len(remaining) != 0 {
+			t.Errorf("Items %v were left in Messages after OnLeftover", remaining)
+		}
+		fanout.Close()
+	}
+}
@@ -0,0 +1,98 @@
+package mpmc
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	consumer := fanout.CreateConsumer(ctx)
+
+	var order []string
+	var observed []int
+	fanout.Use(func(next func(int)) func(int) {
+		return func(item int) {
+			order = append(order, "validate")
+			if item >= 0 {
+				next(item)
+			}
+		}
+	})
+	fanout.Use(func(next func(int)) func(int) {
+		return func(item int) {
+			order = append(order, "enrich")
+			next(item * 10)
+		}
+	})
+	fanout.Use(func(next func(int)) func(int) {
+		return func(item int) {
+			observed = append(observed, item)
+			next(item)
+		}
+	})
+
+	for _, item := range []int{1, -1, 2} {
+		fanout.WriteTracked(item)
+	}
+
+	if got, expected := consumer.DrainBuffered(), []int{10, 20}; !slices.Equal(got, expected) {
+		t.Errorf("Consumer received %v, expected %v", got, expected)
+	}
+	if expected := []int{10, 20}; !slices.Equal(observed, expected) {
+		t.Errorf("Last middleware observed %v, expected %v", observed, expected)
+	}
+	if expected := []string{"validate", "enrich", "validate", "validate", "enrich"}; !slices.Equal(order, expected) {
+		t.Errorf("Middleware ran in order %v, expected %v", order, expected)
+	}
+	if stats := fanout.Stats(); stats.Filtered != 1 || stats.Delivered != 2 {
+		t.Errorf("Stats returned %d filtered and %d delivered, expected 1 and 2", stats.Filtered, stats.Delivered)
+	}
+}
+
+func TestMiddlewareConcurrentWrites(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 64, 64)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	consumer := fanout.CreateConsumer(ctx)
+
+	fanout.Use(func(next func(int)) func(int) {
+		return func(item int) {
+			if item%2 == 0 {
+				next(item * 10)
+			}
+		}
+	})
+
+	// WriteAllSync runs the chain on the calling goroutine while dispatch runs it too
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			if err := fanout.WriteAllSync(ctx, i); err != nil {
+				t.Errorf("WriteAllSync returned %v", err)
+			}
+		}
+	}()
+	for i := 10; i < 20; i++ {
+		fanout.WriteTracked(i)
+	}
+	<-done
+
+	got := consumer.DrainBuffered()
+	slices.Sort(got)
+	if expected := []int{0, 20, 40, 60, 80, 100, 120, 140, 160, 180}; !slices.Equal(got, expected) {
+		t.Errorf("Consumer received %v, expected %v", got, expected)
+	}
+	if filtered := fanout.Stats().Filtered; filtered != 10 {
+		t.Errorf("Stats returned %d filtered, expected 10", filtered)
+	}
+}
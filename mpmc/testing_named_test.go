@@ -0,0 +1,42 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetOrCreateConsumer(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	first := fanout.GetOrCreateConsumer(ctx, "client")
+	if again := fanout.GetOrCreateConsumer(ctx, "client"); again != first {
+		t.Error("GetOrCreateConsumer created a second consumer for the same name")
+	}
+	if first.Name() != "client" {
+		t.Errorf("Name is %q, expected client", first.Name())
+	}
+	if other := fanout.GetOrCreateConsumer(ctx, "other"); other == first {
+		t.Error("GetOrCreateConsumer returned the same consumer for different names")
+	}
+	if count := len(fanout.ConsumerInfo()); count != 2 {
+		t.Errorf("Producer has %d consumers, expected 2", count)
+	}
+
+	// A cancelled consumer is replaced even before the Producer has removed it
+	first.Close()
+	replaced := fanout.GetOrCreateConsumer(ctx, "client")
+	if replaced == first {
+		t.Error("GetOrCreateConsumer returned a closed consumer")
+	}
+	if id, _ := fanout.WriteTracked(1); id == first.Id() {
+		t.Error("Closed consumer received an item")
+	}
+	if len(replaced.Messages) != 1 {
+		t.Error("Replacement consumer did not receive the item")
+	}
+}
@@ -0,0 +1,47 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOccupancyBucket(t *testing.T) {
+	for _, c := range []struct{ length, capacity, bucket int }{
+		{0, 10, 0}, {1, 10, 1}, {5, 16, 3}, {9, 10, 9}, {10, 10, 9}, {1, 1, 9},
+	} {
+		if bucket := occupancyBucket(c.length, c.capacity); bucket != c.bucket {
+			t.Errorf("occupancyBucket(%d, %d) is %d, expected %d", c.length, c.capacity, bucket, c.bucket)
+		}
+	}
+}
+
+func TestOccupancySampling(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 4, WithOccupancySampling[int](time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	for i := 0; i < 4; i++ {
+		fanout.WriteTracked(i)
+	}
+	for consumer.Stats().Occupancy[OccupancyBuckets-1] < 3 {
+		if ctx.Err() != nil {
+			t.Fatalf("Occupancy is %v, expected samples of a full buffer", consumer.Stats().Occupancy)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := fanout.ShutdownOrdered(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// ShutdownOrdered does not wait for the background goroutines to exit
+	for fanout.GoroutineCount() != 0 {
+		select {
+		case <-time.After(time.Millisecond):
+		case <-ctx.Done():
+			t.Fatalf("GoroutineCount is %d after shutdown, expected the sampler stopped", fanout.GoroutineCount())
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package mpmc
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOrderCheck(t *testing.T) {
+	type gap struct {
+		id            string
+		expected, got int64
+	}
+	var gaps []gap
+	fanout := NewProducer[int](ProducerKind_All, 16, 1, WithOrderCheck[int](func(id string, expected, got int64) {
+		gaps = append(gaps, gap{id, expected, got})
+	}))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	a := fanout.CreateConsumer(ctx)
+	b := fanout.CreateConsumer(ctx)
+
+	fanout.WriteTracked(0)
+	fanout.WriteTracked(1) // missed by both
+	a.DrainBuffered()
+	fanout.WriteTracked(2) // missed by b
+	b.DrainBuffered()
+	fanout.WriteTracked(3) // missed by a
+
+	if expected := []gap{{a.Id(), 2, 3}, {b.Id(), 2, 4}}; !slices.Equal(gaps, expected) {
+		t.Errorf("Order check reported %v, expected %v", gaps, expected)
+	}
+}
@@ -0,0 +1,59 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAddOutputChannel(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	ch := make(chan int, 16)
+	remove := fanout.AddOutputChannel(ch)
+
+	if err := fanout.WaitForConsumers(ctx, 1); err != nil {
+		t.Fatalf("WaitForConsumers returned %v", err)
+	}
+	if err := fanout.Write(1); err != nil {
+		t.Fatalf("Write returned %v", err)
+	}
+
+	select {
+	case item := <-ch:
+		if item != 1 {
+			t.Errorf("Output channel received %d, expected 1", item)
+		}
+	case <-ctx.Done():
+		t.Fatal("Output channel did not receive the item")
+	}
+
+	remove()
+	remove()
+
+	for {
+		fanout.consumers_mu.Lock()
+		count := len(fanout.consumers)
+		fanout.consumers_mu.Unlock()
+		if count == 0 {
+			break
+		}
+		select {
+		case <-time.After(time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("Output channel was not removed")
+		}
+	}
+
+	if err := fanout.Write(2); err != nil {
+		t.Fatalf("Write returned %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(ch) != 0 {
+		t.Errorf("Output channel received %d items after removal, expected 0", len(ch))
+	}
+}
@@ -0,0 +1,47 @@
+package mpmc
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOverflowConsumer(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 2)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	overflow := fanout.CreateConsumer(ctx)
+	fanout.SetOverflowConsumer(overflow)
+
+	// No consumers left for normal selection
+	fanout.WriteTracked(1)
+
+	consumer := fanout.CreateConsumer(ctx)
+	for i := 2; i <= 5; i++ {
+		fanout.WriteTracked(i)
+	}
+
+	got := append(consumer.DrainBuffered(), overflow.DrainBuffered()...)
+	if expected := []int{2, 3, 1, 4}; !slices.Equal(got, expected) {
+		t.Errorf("Consumer and overflow consumer received %v, expected %v", got, expected)
+	}
+
+	// The overflow consumer was full for the last item
+	if stats := fanout.Stats(); stats.Overflowed != 2 {
+		t.Errorf("Overflowed is %d, expected 2", stats.Overflowed)
+	}
+	if counts := fanout.DropCounts(); counts[DropNoConsumers] != 0 || counts[DropConsumerFull] != 1 {
+		t.Errorf("DropCounts is %v, expected only the item the overflow consumer had no room for", counts)
+	}
+
+	fanout.Close()
+	select {
+	case <-overflow.ctx.Done():
+	case <-ctx.Done():
+		t.Error("Overflow consumer was not closed with the Producer")
+	}
+}
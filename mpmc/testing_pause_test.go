@@ -0,0 +1,44 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPauseResume(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 4, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	fanout.Pause()
+
+	for i := 0; i < 4; i++ {
+		if err := fanout.Write(i); err != nil {
+			t.Fatalf("Write %d while paused returned %v", i, err)
+		}
+	}
+	if err := fanout.Write(4); err != ErrBufferFull {
+		t.Errorf("Write to a full input while paused returned %v, expected %v", err, ErrBufferFull)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if len(consumer.Messages) != 0 {
+		t.Fatalf("Consumer received %d items while paused, expected 0", len(consumer.Messages))
+	}
+
+	fanout.Resume()
+	for i := 0; i < 4; i++ {
+		select {
+		case item := <-consumer.Messages:
+			if item != i {
+				t.Errorf("Consumer received %d, expected %d", item, i)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Consumer did not receive item %d after resume", i)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPinTo(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	a := fanout.CreateConsumer(ctx)
+	b := fanout.CreateConsumer(ctx)
+	c := fanout.CreateConsumer(ctx)
+
+	if err := fanout.PinTo("missing"); err != ErrUnknownConsumer {
+		t.Errorf("PinTo returned %v for an unknown consumer, expected %v", err, ErrUnknownConsumer)
+	}
+	if err := fanout.PinTo(b.Id()); err != nil {
+		t.Fatalf("PinTo returned %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if id, _ := fanout.WriteTracked(i); id != b.Id() {
+			t.Errorf("Item %d went to %s while pinned, expected %s", i, id, b.Id())
+		}
+	}
+	if len(b.Messages) != 4 {
+		t.Errorf("Pinned consumer holds %d items, expected 4", len(b.Messages))
+	}
+
+	if id, ok := fanout.WouldSelect(4); !ok || id != b.Id() {
+		t.Errorf("WouldSelect returned %s while pinned, expected %s", id, b.Id())
+	}
+
+	// The pin outlives the consumer's removal
+	b.Close()
+	deadline := time.Now().Add(time.Second)
+	for len(fanout.ConsumerInfo()) != 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if id, ok := fanout.WouldSelect(4); ok {
+		t.Errorf("WouldSelect returned %s for a removed pinned consumer", id)
+	}
+	if id, _ := fanout.WriteTracked(4); id != "" {
+		t.Errorf("Item went to %s while pinned to a removed consumer, expected it dropped", id)
+	}
+	if dropped := fanout.DropCounts()[DropNoConsumers]; dropped != 1 {
+		t.Errorf("Expected 1 item dropped for the removed pinned consumer, got %d", dropped)
+	}
+
+	fanout.Unpin()
+	for i := 5; i < 7; i++ {
+		fanout.WriteTracked(i)
+	}
+	if len(a.Messages) != 1 || len(c.Messages) != 1 {
+		t.Errorf("After Unpin the LRU strategy delivered %d and %d items, expected 1 each", len(a.Messages), len(c.Messages))
+	}
+}
+
+func TestPinToClosedConsumer(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16, WithManualConsumerRemoval[int]())
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	fanout.CreateConsumer(ctx)
+	pinned := fanout.CreateConsumer(ctx)
+	if err := fanout.PinTo(pinned.Id()); err != nil {
+		t.Fatalf("PinTo returned %v", err)
+	}
+
+	// A closed consumer stays attached until it is removed, and the pin drops its items
+	pinned.Close()
+	if id, ok := fanout.WouldSelect(0); ok {
+		t.Errorf("WouldSelect returned %s for a closed pinned consumer", id)
+	}
+	if id, _ := fanout.WriteTracked(0); id != "" {
+		t.Errorf("Item went to %s while pinned to a closed consumer, expected it dropped", id)
+	}
+	if dropped := fanout.DropCounts()[DropNoConsumers]; dropped != 1 {
+		t.Errorf("Expected 1 item dropped for the closed pinned consumer, got %d", dropped)
+	}
+	fanout.RemoveConsumer(pinned.Id())
+	if id, _ := fanout.WriteTracked(1); id != "" {
+		t.Errorf("Item went to %s while pinned to a removed consumer, expected it dropped", id)
+	}
+	if dropped := fanout.DropCounts()[DropNoConsumers]; dropped != 2 {
+		t.Errorf("Expected 2 items dropped for the pinned consumer, got %d", dropped)
+	}
+}
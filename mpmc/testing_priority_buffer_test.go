@@ -0,0 +1,71 @@
+package mpmc
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPriorityQueueEviction(t *testing.T) {
+	q := newPriorityQueue(3, func(item int) int { return item / 10 })
+
+	for _, item := range []int{10, 11, 20} {
+		if added, _, evicted := q.push(item); !added || evicted {
+			t.Fatalf("push(%d) returned %v, %v, expected the item added without eviction", item, added, evicted)
+		}
+	}
+	// Full: an item of the lowest priority is rejected, a higher one evicts the newest lowest
+	if added, _, _ := q.push(12); added {
+		t.Error("push(12) added an item of the lowest buffered priority to a full queue")
+	}
+	if added, evicted, ok := q.push(30); !added || !ok || evicted != 11 {
+		t.Errorf("push(30) returned %v, %v, %v, expected the item added and 11 evicted", added, evicted, ok)
+	}
+
+	var got []int
+	for item, ok := q.pop(); ok; item, ok = q.pop() {
+		got = append(got, item)
+	}
+	if expected := []int{30, 20, 10}; !slices.Equal(got, expected) {
+		t.Errorf("Items popped in order %v, expected %v", got, expected)
+	}
+}
+
+func TestPriorityConsumerBuffers(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 2, WithPriorityConsumerBuffers(func(item int) int { return item }))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	consumer := fanout.CreateConsumer(ctx)
+
+	// The first item is held by the pump until it is read
+	fanout.WriteTracked(0)
+	for consumer.ranked.len() != 0 {
+		if ctx.Err() != nil {
+			t.Fatal("The first item was not taken from the buffer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, item := range []int{1, 2, 5, 0} {
+		fanout.WriteTracked(item)
+	}
+
+	var got []int
+	for len(got) < 3 {
+		select {
+		case item := <-consumer.Messages:
+			got = append(got, item)
+		case <-ctx.Done():
+			t.Fatalf("Received %v, expected 3 items", got)
+		}
+	}
+	if expected := []int{0, 5, 2}; !slices.Equal(got, expected) {
+		t.Errorf("Received %v, expected %v", got, expected)
+	}
+	if drops := fanout.DropCounts()[DropConsumerFull]; drops != 2 {
+		t.Errorf("DropCounts[DropConsumerFull] is %d, expected one eviction and one rejected item", drops)
+	}
+}
@@ -0,0 +1,32 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAllPriorityOrder(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 4)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	low := fanout.CreateConsumer(ctx)
+	high := fanout.CreateConsumerWithPriority(ctx, 10)
+	fanout.CreateConsumerWithPriority(ctx, -1)
+
+	if high.Priority() != 10 || low.Priority() != 0 {
+		t.Fatalf("Priorities are %d and %d, expected 10 and 0", high.Priority(), low.Priority())
+	}
+
+	// WriteTracked reports the first consumer served under All
+	id, err := fanout.WriteTracked(1)
+	if err != nil {
+		t.Fatalf("WriteTracked returned %v", err)
+	}
+	if id != high.Id() {
+		t.Errorf("First consumer served was %s, expected the high priority consumer %s", id, high.Id())
+	}
+}
@@ -0,0 +1,90 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProducerPool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	newPool := func(kind ProducerKind, options ...PoolOption[int]) (*ProducerPool[int], []*Producer[int], []*Consumer[int]) {
+		var producers []*Producer[int]
+		var consumers []*Consumer[int]
+		for i := 0; i < 3; i++ {
+			producer := NewProducer[int](ProducerKind_All, 16, 16)
+			t.Cleanup(producer.Close)
+			producers = append(producers, producer)
+			consumers = append(consumers, producer.CreateConsumer(ctx))
+		}
+		pool, err := NewProducerPool(producers, kind, options...)
+		if err != nil {
+			t.Fatalf("NewProducerPool returned %v", err)
+		}
+		return pool, producers, consumers
+	}
+	// received waits until the consumers together hold total items and returns each one's count
+	received := func(consumers []*Consumer[int], total int) []int {
+		for {
+			counts, sum := make([]int, len(consumers)), 0
+			for i, consumer := range consumers {
+				counts[i] = len(consumer.Messages)
+				sum += counts[i]
+			}
+			if sum >= total || ctx.Err() != nil {
+				return counts
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if _, err := NewProducerPool[int](nil, ProducerKind_Weighted); err != ErrUnsupportedKind {
+		t.Errorf("NewProducerPool returned %v for the Weighted kind, expected ErrUnsupportedKind", err)
+	}
+
+	pool, producers, consumers := newPool(ProducerKind_LRU)
+	for i := 0; i < 6; i++ {
+		if err := pool.Write(i); err != nil {
+			t.Fatalf("Write returned %v", err)
+		}
+	}
+	if counts := received(consumers, 6); counts[0] != 2 || counts[1] != 2 || counts[2] != 2 {
+		t.Errorf("LRU pool spread 6 items as %v, expected 2 each", counts)
+	}
+
+	// A closed Producer is skipped and removed
+	producers[0].Close()
+	for i := 0; i < 4; i++ {
+		if err := pool.Write(i); err != nil {
+			t.Fatalf("Write returned %v with a closed Producer in the pool", err)
+		}
+	}
+	if counts := received(consumers[1:], 8); counts[0] != 4 || counts[1] != 4 {
+		t.Errorf("Open producers hold %v items, expected 4 each", counts)
+	}
+	if len(pool.producers) != 2 {
+		t.Errorf("Pool has %d producers, expected the closed one removed", len(pool.producers))
+	}
+	producers[1].Close()
+	producers[2].Close()
+	if err := pool.Write(0); err != ErrProducerClosed {
+		t.Errorf("Write returned %v with every Producer closed, expected %v", err, ErrProducerClosed)
+	}
+
+	strict, producers, _ := newPool(ProducerKind_LRU, WithPoolFailOnClosed[int]())
+	producers[0].Close()
+	if err := strict.Write(0); err != ErrProducerClosed {
+		t.Errorf("Write returned %v for a closed Producer with WithPoolFailOnClosed, expected %v", err, ErrProducerClosed)
+	}
+	if err := strict.Write(1); err != nil {
+		t.Errorf("Write returned %v after the closed Producer was removed", err)
+	}
+
+	all, _, consumers := newPool(ProducerKind_All)
+	all.Write(0)
+	if counts := received(consumers, 3); counts[0] != 1 || counts[1] != 1 || counts[2] != 1 {
+		t.Errorf("All pool delivered %v items, expected 1 to each Producer", counts)
+	}
+}
@@ -0,0 +1,78 @@
+package mpmc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFanoutPull(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_Pull, 65535, 0)
+	defer fanout.Close()
+
+	numPullers := 5
+	totalItems := 10000
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	results := make([][]int, numPullers)
+
+	for i := 0; i < numPullers; i++ {
+		consumer := fanout.CreateConsumer(ctx)
+
+		wg.Add(1)
+		go func(c *Consumer[int], resultSlice *[]int) {
+			defer wg.Done()
+			for {
+				item, err := c.Pull(ctx)
+				if err != nil {
+					return
+				}
+				*resultSlice = append(*resultSlice, item)
+				if item == totalItems-1 {
+					cancel()
+				}
+			}
+		}(consumer, &results[i])
+	}
+
+	for i := 0; i < totalItems; i++ {
+		if err := fanout.Write(i); err != nil {
+			t.Fatalf("Write returned %v", err)
+		}
+	}
+
+	wg.Wait()
+
+	// Every item must have been pulled exactly once, and in order per puller
+	seen := make([]bool, totalItems)
+	for i, result := range results {
+		for j, item := range result {
+			if seen[item] {
+				t.Fatalf("Item %d was pulled twice", item)
+			}
+			seen[item] = true
+			if j > 0 && item < result[j-1] {
+				t.Errorf("Puller %d received %d after %d", i, item, result[j-1])
+			}
+		}
+	}
+	for item, ok := range seen {
+		if !ok {
+			t.Errorf("Item %d was never pulled", item)
+		}
+	}
+}
+
+func TestPullNotPullMode(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_Single, 1, 1)
+	defer fanout.Close()
+
+	consumer := fanout.CreateConsumer(context.Background())
+	if _, err := consumer.Pull(context.Background()); err != ErrNotPullMode {
+		t.Errorf("Pull returned %v, expected %v", err, ErrNotPullMode)
+	}
+}
@@ -0,0 +1,44 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestConsumerRateLimited(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	limited := fanout.CreateConsumerRateLimited(ctx, 50)
+	unlimited := fanout.CreateConsumer(ctx)
+
+	numItems := 10
+	for i := 0; i < numItems; i++ {
+		fanout.WriteTracked(i)
+	}
+	if len(unlimited.Messages) != numItems {
+		t.Errorf("Unlimited consumer has %d items, expected %d", len(unlimited.Messages), numItems)
+	}
+
+	start := time.Now()
+	for i := 0; i < numItems; i++ {
+		select {
+		case item := <-limited.Messages:
+			if item != i {
+				t.Errorf("Received %d, expected %d", item, i)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Received only %d items", i)
+		}
+	}
+
+	// The first item is released at once, the others every 20ms
+	elapsed := time.Since(start)
+	if elapsed < 170*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Errorf("Receiving %d items at 50 per second took %v, expected about 180ms", numItems, elapsed)
+	}
+}
@@ -0,0 +1,41 @@
+package mpmc
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16, WithReplay[int](3))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	// Items dispatched without consumers are replayed too
+	for i := 0; i < 5; i++ {
+		fanout.WriteTracked(i)
+	}
+
+	durable := fanout.CreateConsumer(ctx)
+	ephemeral := fanout.CreateConsumerEphemeral(ctx)
+	fanout.WriteTracked(5)
+
+	if got, expected := durable.DrainBuffered(), []int{2, 3, 4, 5}; !slices.Equal(got, expected) {
+		t.Errorf("Durable consumer received %v, expected %v", got, expected)
+	}
+	if got, expected := ephemeral.DrainBuffered(), []int{5}; !slices.Equal(got, expected) {
+		t.Errorf("Ephemeral consumer received %v, expected %v", got, expected)
+	}
+
+	small := NewProducer[int](ProducerKind_All, 16, 2, WithReplay[int](3))
+	defer small.Close()
+	for i := 0; i < 5; i++ {
+		small.WriteTracked(i)
+	}
+	if got, expected := small.CreateConsumer(ctx).DrainBuffered(), []int{3, 4}; !slices.Equal(got, expected) {
+		t.Errorf("Consumer with a small buffer received %v, expected the most recent %v", got, expected)
+	}
+}
@@ -0,0 +1,37 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFanoutReportedLoad(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_ReportedLoad, 16, 16, WithLoadStaleAfter[int](50*time.Millisecond))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	busy := fanout.CreateConsumer(ctx)
+	idle := fanout.CreateConsumer(ctx)
+	silent := fanout.CreateConsumer(ctx)
+
+	busy.ReportLoad(10)
+	idle.ReportLoad(1)
+	for i := 0; i < 3; i++ {
+		if id, _ := fanout.WriteTracked(i); id != idle.Id() {
+			t.Errorf("Item %d went to %s, expected the least loaded consumer %s", i, id, idle.Id())
+		}
+	}
+	if len(silent.Messages) != 0 {
+		t.Error("Consumer that never reported a load received items")
+	}
+
+	// Once the idle consumer's report goes stale it counts as fully loaded
+	time.Sleep(60 * time.Millisecond)
+	busy.ReportLoad(10)
+	if id, _ := fanout.WriteTracked(3); id != busy.Id() {
+		t.Errorf("Item went to %s, expected the only consumer with a fresh report %s", id, busy.Id())
+	}
+}
@@ -0,0 +1,70 @@
+package mpmc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDispatchRestartAfterPanic(t *testing.T) {
+	var panics atomic.Int64
+	panics.Store(1)
+	score := func(weight uint, idle time.Duration) float64 {
+		if panics.Add(-1) >= 0 {
+			panic("injected panic")
+		}
+		return DefaultWeightedLRUScore(weight, idle)
+	}
+
+	fanout := NewProducer[int](ProducerKind_WeightedLRU, 16, 16, WithWeightedLRUScore[int](score))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	consumer := fanout.CreateConsumer(ctx)
+
+	// The panicking dispatch reports the item as dropped instead of leaving the writer waiting
+	if id, err := fanout.WriteTracked(1); err != nil || id != "" {
+		t.Errorf("WriteTracked returned %q, %v for the panicking dispatch", id, err)
+	}
+
+	if id, err := fanout.WriteTracked(2); err != nil || id != consumer.Id() {
+		t.Errorf("WriteTracked returned %q, %v after the restart", id, err)
+	}
+
+	if restarts := fanout.Stats().DispatchRestarts; restarts != 1 {
+		t.Errorf("DispatchRestarts is %d, expected 1", restarts)
+	}
+}
+
+func TestDispatchRestartLimit(t *testing.T) {
+	score := func(weight uint, idle time.Duration) float64 {
+		panic("injected panic")
+	}
+
+	fanout := NewProducer[int](ProducerKind_WeightedLRU, 16, 16, WithWeightedLRUScore[int](score))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	fanout.CreateConsumer(ctx)
+
+	for i := 0; i < MaxDispatchRestarts; i++ {
+		if _, err := fanout.WriteTracked(i); err != nil {
+			t.Fatalf("WriteTracked returned %v before the restart limit was reached", err)
+		}
+	}
+	// This panic exceeds the limit, so the write may see the Producer close
+	fanout.WriteTracked(MaxDispatchRestarts)
+
+	if err := fanout.ShutdownOrdered(ctx); err != nil {
+		t.Fatalf("Producer did not close after the restart limit: %v", err)
+	}
+	if err := fanout.Write(0); err != ErrProducerClosed {
+		t.Errorf("Write returned %v, expected ErrProducerClosed", err)
+	}
+	if restarts := fanout.Stats().DispatchRestarts; restarts != MaxDispatchRestarts {
+		t.Errorf("DispatchRestarts is %d, expected %d", restarts, MaxDispatchRestarts)
+	}
+}
@@ -0,0 +1,71 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWriteRetry(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 1, 16, WithRetryQueue[int](2, time.Millisecond, 5*time.Millisecond))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	consumer := fanout.CreateConsumer(ctx)
+
+	// Paused, so the first item fills the input buffer and the next two are parked
+	fanout.Pause()
+	for i := 0; i < 3; i++ {
+		if err := fanout.WriteRetry(i); err != nil {
+			t.Fatalf("WriteRetry returned %v", err)
+		}
+	}
+	if err := fanout.WriteRetry(3); err != ErrRetryQueueFull {
+		t.Errorf("WriteRetry returned %v with a full retry queue, expected ErrRetryQueueFull", err)
+	}
+	if pending := fanout.Stats().RetryPending; pending != 2 {
+		t.Errorf("RetryPending is %d, expected 2", pending)
+	}
+
+	fanout.Resume()
+	var received []int
+	for len(received) < 3 {
+		select {
+		case item := <-consumer.Messages:
+			received = append(received, item)
+		case <-ctx.Done():
+			t.Fatalf("Received %v, expected the parked items to follow", received)
+		}
+	}
+	if !equalSlices(received, []int{0, 1, 2}) {
+		t.Errorf("Received %v, expected [0 1 2]", received)
+	}
+	if dropped := fanout.DropCounts()[DropRetryOverflow]; dropped != 1 {
+		t.Errorf("DropRetryOverflow is %d, expected 1", dropped)
+	}
+}
+
+func TestWriteRetryDiscardedOnClose(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 1, 16, WithRetryQueue[int](4, time.Millisecond, time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	fanout.Pause()
+	for i := 0; i < 3; i++ {
+		fanout.WriteRetry(i)
+	}
+	fanout.ShutdownOrdered(ctx)
+
+	for fanout.Stats().RetryPending > 0 {
+		select {
+		case <-ctx.Done():
+			t.Fatal("Retry queue was not discarded on close")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if dropped := fanout.DropCounts()[DropClosed]; dropped != 2 {
+		t.Errorf("DropClosed is %d, expected 2", dropped)
+	}
+}
@@ -0,0 +1,42 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWriteRouted(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_Single, 16, 16)
+	defer fanout.Close()
+
+	a := fanout.CreateConsumer(context.Background())
+	b := fanout.CreateConsumer(context.Background())
+	c := fanout.CreateConsumer(context.Background())
+
+	if err := fanout.AddRoute("broadcast", ProducerKind_All, []string{a.Id(), b.Id()}); err != nil {
+		t.Fatalf("AddRoute returned %v", err)
+	}
+	if err := fanout.AddRoute("pull", ProducerKind_Pull, nil); err != ErrUnsupportedKind {
+		t.Errorf("AddRoute with pull kind returned %v, expected %v", err, ErrUnsupportedKind)
+	}
+
+	if err := fanout.WriteRouted("broadcast", 1); err != nil {
+		t.Fatalf("WriteRouted returned %v", err)
+	}
+	if err := fanout.WriteRouted("missing", 2); err != ErrUnknownRoute {
+		t.Errorf("WriteRouted to a missing route returned %v, expected %v", err, ErrUnknownRoute)
+	}
+	// A tracked write is dispatched after the routed one, so the routed item has been delivered
+	fanout.WriteTracked(3)
+	fanout.RemoveRoute("broadcast")
+	if err := fanout.WriteRouted("broadcast", 4); err != ErrUnknownRoute {
+		t.Errorf("WriteRouted to a removed route returned %v, expected %v", err, ErrUnknownRoute)
+	}
+
+	if len(a.Messages) == 0 || len(b.Messages) == 0 || <-a.Messages != 1 || <-b.Messages != 1 {
+		t.Error("Route consumers did not both receive the routed item")
+	}
+	if total := len(a.Messages) + len(b.Messages) + len(c.Messages); total != 1 {
+		t.Errorf("Consumers hold %d unrouted items, expected 1", total)
+	}
+}
@@ -0,0 +1,53 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseSelector(t *testing.T) {
+	sel, err := ParseSelector("env=prod AND region=us")
+	if err != nil {
+		t.Fatalf("ParseSelector returned %v", err)
+	}
+	if len(sel) != 2 || sel["env"] != "prod" || sel["region"] != "us" {
+		t.Errorf("ParseSelector returned %v", sel)
+	}
+	if _, err := ParseSelector("env AND region=us"); err == nil {
+		t.Error("ParseSelector accepted a term without =")
+	}
+}
+
+func TestWriteSelector(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	prodUS := fanout.CreateConsumerWithTags(ctx, map[string]string{"env": "prod", "region": "us"})
+	prodEU := fanout.CreateConsumerWithTags(ctx, map[string]string{"env": "prod", "region": "eu"})
+	untagged := fanout.CreateConsumer(ctx)
+
+	if err := fanout.WriteSelector(Selector{"env": "prod", "region": "us"}, 1); err != nil {
+		t.Fatalf("WriteSelector returned %v", err)
+	}
+	if err := fanout.WriteSelector(Selector{"env": "prod"}, 2); err != nil {
+		t.Fatalf("WriteSelector returned %v", err)
+	}
+	if err := fanout.WriteSelector(Selector{"env": "dev"}, 3); err != ErrNoMatchingConsumers {
+		t.Errorf("WriteSelector returned %v without a match, expected ErrNoMatchingConsumers", err)
+	}
+	fanout.WriteTracked(4)
+
+	if received := prodUS.DrainBuffered(); !equalSlices(received, []int{1, 2, 4}) {
+		t.Errorf("prod/us consumer received %v", received)
+	}
+	if received := prodEU.DrainBuffered(); !equalSlices(received, []int{2, 4}) {
+		t.Errorf("prod/eu consumer received %v", received)
+	}
+	if received := untagged.DrainBuffered(); !equalSlices(received, []int{4}) {
+		t.Errorf("Untagged consumer received %v", received)
+	}
+}
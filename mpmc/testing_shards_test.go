@@ -0,0 +1,41 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWriteShard(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	even := fanout.CreateConsumerForShards(ctx, 0, 2)
+	evenToo := fanout.CreateConsumerForShards(ctx, 2)
+	odd := fanout.CreateConsumerForShards(ctx, 1)
+
+	if err := fanout.WriteShard(3, 30); err != ErrNoShardConsumers {
+		t.Errorf("WriteShard returned %v for a shard without consumers, expected ErrNoShardConsumers", err)
+	}
+
+	for _, shard := range []int{0, 1, 2} {
+		if err := fanout.WriteShard(shard, shard*10); err != nil {
+			t.Fatalf("WriteShard returned %v", err)
+		}
+	}
+	fanout.WriteTracked(-1)
+
+	// Shard items are broadcast within the shard, plain writes go to everyone
+	if received := even.DrainBuffered(); !equalSlices(received, []int{0, 20, -1}) {
+		t.Errorf("Consumer of shards 0 and 2 received %v", received)
+	}
+	if received := evenToo.DrainBuffered(); !equalSlices(received, []int{20, -1}) {
+		t.Errorf("Consumer of shard 2 received %v", received)
+	}
+	if received := odd.DrainBuffered(); !equalSlices(received, []int{10, -1}) {
+		t.Errorf("Consumer of shard 1 received %v", received)
+	}
+}
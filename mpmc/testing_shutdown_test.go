@@ -0,0 +1,272 @@
+package mpmc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownOrdered(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumers := make([]*Consumer[int], 3)
+	for i := range consumers {
+		consumers[i] = fanout.CreateConsumer(ctx)
+	}
+	for i := 0; i < 5; i++ {
+		fanout.Write(i)
+	}
+
+	if err := fanout.ShutdownOrdered(ctx); err != nil {
+		t.Fatalf("ShutdownOrdered returned %v", err)
+	}
+
+	if err := fanout.Write(5); err != ErrProducerClosed {
+		t.Errorf("Write after shutdown returned %v, expected %v", err, ErrProducerClosed)
+	}
+	if infos := fanout.ConsumerInfo(); len(infos) != 0 {
+		t.Errorf("Producer still has %d consumers after shutdown", len(infos))
+	}
+	for i, consumer := range consumers {
+		select {
+		case <-consumer.ctx.Done():
+		default:
+			t.Errorf("Consumer %d was not cancelled", i)
+		}
+	}
+
+	late := fanout.CreateConsumer(ctx)
+	select {
+	case <-late.ctx.Done():
+	default:
+		t.Error("Consumer created after shutdown was not cancelled")
+	}
+}
+
+func TestGoroutineCount(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	numConsumers := 3
+	for i := 0; i < numConsumers; i++ {
+		fanout.CreateConsumer(ctx)
+	}
+	fanout.AddInput(make(chan int))
+
+	// Dispatch, close watcher, one watcher per consumer and the input merge
+	if count := fanout.GoroutineCount(); count != numConsumers+3 {
+		t.Errorf("GoroutineCount is %d, expected %d", count, numConsumers+3)
+	}
+
+	if err := fanout.ShutdownOrdered(ctx); err != nil {
+		t.Fatalf("ShutdownOrdered returned %v", err)
+	}
+
+	for fanout.GoroutineCount() != 0 {
+		select {
+		case <-time.After(time.Millisecond):
+		case <-ctx.Done():
+			t.Fatalf("GoroutineCount is %d after shutdown, expected 0", fanout.GoroutineCount())
+		}
+	}
+}
+
+func TestGoroutineLimit(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	if ActiveGoroutines() < fanout.GoroutineCount() {
+		t.Errorf("ActiveGoroutines is %d, expected at least %d", ActiveGoroutines(), fanout.GoroutineCount())
+	}
+
+	defer SetGoroutineLimit(0)
+
+	SetGoroutineLimit(1 << 20)
+	if _, err := fanout.TryCreateConsumer(context.Background()); err != nil {
+		t.Fatalf("TryCreateConsumer returned %v", err)
+	}
+
+	// This Producer alone already runs more goroutines than that
+	SetGoroutineLimit(1)
+	if _, err := fanout.TryCreateConsumer(context.Background()); err != ErrGoroutineLimit {
+		t.Errorf("TryCreateConsumer returned %v, expected %v", err, ErrGoroutineLimit)
+	}
+}
+
+func TestManualConsumerRemoval(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16, WithManualConsumerRemoval[int]())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumerCtx, consumerCancel := context.WithCancel(ctx)
+	cancelled := fanout.CreateConsumer(consumerCtx)
+	kept := fanout.CreateConsumer(ctx)
+
+	// Only the producer's own goroutines, no watchers
+	if count := fanout.GoroutineCount(); count != 2 {
+		t.Errorf("GoroutineCount is %d, expected 2", count)
+	}
+
+	consumerCancel()
+	fanout.WriteTracked(1)
+
+	if infos := fanout.ConsumerInfo(); len(infos) != 2 {
+		t.Errorf("Producer has %d consumers, expected the cancelled one to stay attached", len(infos))
+	}
+	if len(cancelled.Messages) != 0 {
+		t.Error("Cancelled consumer received an item")
+	}
+	if len(kept.Messages) != 1 {
+		t.Error("Active consumer did not receive the item")
+	}
+
+	if !fanout.RemoveConsumer(cancelled.Id()) {
+		t.Error("RemoveConsumer did not find the cancelled consumer")
+	}
+	if fanout.RemoveConsumer(cancelled.Id()) {
+		t.Error("RemoveConsumer removed the same consumer twice")
+	}
+
+	if err := fanout.ShutdownOrdered(ctx); err != nil {
+		t.Errorf("ShutdownOrdered returned %v", err)
+	}
+}
+
+func TestCloseDraining(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	fanout.CreateConsumer(ctx)
+
+	// Two items fit the consumer buffer, the third is dropped
+	fanout.Pause()
+	for i := 0; i < 3; i++ {
+		fanout.Write(i)
+	}
+	fanout.Resume()
+
+	delivered, dropped, leftover := fanout.CloseDraining(ctx)
+	if delivered != 2 || dropped != 1 || len(leftover) != 0 {
+		t.Errorf("CloseDraining returned %d delivered, %d dropped, leftover %v, expected 2, 1 and none",
+			delivered, dropped, leftover)
+	}
+	if err := fanout.Write(3); err != ErrProducerClosed {
+		t.Errorf("Write returned %v after CloseDraining, expected ErrProducerClosed", err)
+	}
+}
+
+func TestCloseDrainingLeftover(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	fanout.CreateConsumer(ctx)
+
+	// Paused, so nothing drains before the context expires
+	fanout.Pause()
+	for i := 0; i < 3; i++ {
+		fanout.Write(i)
+	}
+
+	drainCtx, drainCancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer drainCancel()
+	delivered, dropped, leftover := fanout.CloseDraining(drainCtx)
+	if delivered != 0 || dropped != 0 || !equalSlices(leftover, []int{0, 1, 2}) {
+		t.Errorf("CloseDraining returned %d delivered, %d dropped, leftover %v, expected 0, 0 and [0 1 2]",
+			delivered, dropped, leftover)
+	}
+}
+
+func TestCloseRacesConsumerCancel(t *testing.T) {
+	for round := 0; round < 50; round++ {
+		fanout := NewProducer[int](ProducerKind_All, 16, 4, WithEventBuffer[int](256))
+
+		var consumers []*Consumer[int]
+		var cancels []context.CancelFunc
+		for i := 0; i < 10; i++ {
+			ctx, cancel := context.WithCancel(context.Background())
+			consumers = append(consumers, fanout.CreateConsumer(ctx))
+			cancels = append(cancels, cancel)
+		}
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var late []*Consumer[int]
+		start := make(chan struct{})
+		for i := range consumers {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				cancels[i]()
+			}(i)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				consumers[i].Close()
+				// Consumers created while the Producer closes must not be left open
+				consumer := fanout.CreateConsumer(context.Background())
+				mu.Lock()
+				late = append(late, consumer)
+				mu.Unlock()
+			}(i)
+		}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-start
+			fanout.Close()
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			for i := 0; i < 10; i++ {
+				fanout.Write(i)
+			}
+		}()
+		close(start)
+		wg.Wait()
+		<-fanout.closed
+
+		for _, consumer := range append(consumers, late...) {
+			select {
+			case <-consumer.ctx.Done():
+			case <-time.After(time.Second):
+				t.Fatalf("Consumer %s was left open", consumer.Id())
+			}
+		}
+		deadline := time.Now().Add(time.Second)
+		for len(fanout.ConsumerInfo()) != 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if count := len(fanout.ConsumerInfo()); count != 0 {
+			t.Fatalf("%d consumers still attached after Close", count)
+		}
+
+		added, removed := map[string]int{}, map[string]int{}
+		for len(fanout.Events()) > 0 {
+			switch e := <-fanout.Events(); e.Kind {
+			case EventConsumerAdded:
+				added[e.ConsumerID]++
+			case EventConsumerRemoved:
+				removed[e.ConsumerID]++
+			}
+		}
+		for id := range added {
+			if removed[id] != 1 {
+				t.Fatalf("Consumer %s was removed %d times", id, removed[id])
+			}
+		}
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package mpmc
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStartupBuffer(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16, WithStartupBuffer[int](3))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		fanout.WriteTracked(i)
+	}
+	if dropped := fanout.DropCounts()[DropNoConsumers]; dropped != 2 {
+		t.Errorf("Expected the 2 items past the startup buffer to be dropped, got %d", dropped)
+	}
+
+	first := fanout.CreateConsumer(ctx)
+	if got, expected := first.DrainBuffered(), []int{0, 1, 2}; !slices.Equal(got, expected) {
+		t.Errorf("First consumer received %v, expected the held items %v", got, expected)
+	}
+
+	// The buffer drained once, later consumers and later gaps start empty
+	second := fanout.CreateConsumer(ctx)
+	if got := second.DrainBuffered(); len(got) != 0 {
+		t.Errorf("Second consumer received %v, expected nothing", got)
+	}
+	first.Close()
+	second.Close()
+	for len(fanout.ConsumerInfo()) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	fanout.WriteTracked(5)
+	if dropped := fanout.DropCounts()[DropNoConsumers]; dropped != 3 {
+		t.Errorf("Expected items written after the first consumer to be dropped without consumers, got %d drops", dropped)
+	}
+}
+
+func TestStartupBufferDiscardedOnClose(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16, WithStartupBuffer[int](3))
+	fanout.WriteTracked(0)
+	fanout.WriteTracked(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	if err := fanout.ShutdownOrdered(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if closed := fanout.DropCounts()[DropClosed]; closed != 2 {
+		t.Errorf("Expected the 2 held items to be dropped as DropClosed, got %d", closed)
+	}
+}
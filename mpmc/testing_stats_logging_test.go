@@ -0,0 +1,60 @@
+package mpmc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gompmc/logger"
+)
+
+func TestStatsString(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 1)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	fanout.CreateConsumer(ctx)
+	fanout.WriteTracked(1)
+	fanout.WriteTracked(2)
+
+	expected := "  buffered: 0\n  consumers: 1\n  delivered: 1\n  dropped: 1\n  sequence: 2\n"
+	if got := fanout.StatsString(); got != expected {
+		t.Errorf("StatsString returned %q, expected %q", got, expected)
+	}
+}
+
+func TestStatsLogging(t *testing.T) {
+	var buf syncBuffer
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16,
+		WithStatsLogging[int](time.Millisecond),
+		WithLogLevel[int](logger.LogLevelInfo),
+		WithSlog[int](slog.New(slog.NewTextHandler(&buf, nil))))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	fanout.CreateConsumer(ctx)
+	fanout.WriteTracked(1)
+	for !strings.Contains(buf.String(), "delivered: 1") {
+		if ctx.Err() != nil {
+			t.Fatalf("Stats were not logged:\n%s", buf.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := fanout.ShutdownOrdered(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// ShutdownOrdered does not wait for the background goroutines to exit
+	for fanout.GoroutineCount() != 0 {
+		select {
+		case <-time.After(time.Millisecond):
+		case <-ctx.Done():
+			t.Fatalf("GoroutineCount is %d after shutdown, expected the stats logger stopped", fanout.GoroutineCount())
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueueLatency(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	if stats := fanout.Stats(); stats.QueueLatencyAvg != 0 || stats.QueueLatencyMax != 0 {
+		t.Errorf("Latency is %v avg, %v max before any delivery", stats.QueueLatencyAvg, stats.QueueLatencyMax)
+	}
+
+	// Items wait in the input buffer while paused
+	fanout.CreateConsumer(ctx)
+	fanout.Pause()
+	fanout.Write(1)
+	fanout.Write(2)
+	time.Sleep(20 * time.Millisecond)
+	fanout.Resume()
+	fanout.WriteTracked(3)
+
+	stats := fanout.Stats()
+	if stats.QueueLatencyMax < 20*time.Millisecond {
+		t.Errorf("QueueLatencyMax is %v, expected at least 20ms", stats.QueueLatencyMax)
+	}
+	if stats.QueueLatencyAvg <= 0 || stats.QueueLatencyAvg > stats.QueueLatencyMax {
+		t.Errorf("QueueLatencyAvg is %v with a max of %v", stats.QueueLatencyAvg, stats.QueueLatencyMax)
+	}
+}
+
+func TestStatsAndReset(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16, WithInputFilter(func(item int) bool { return item >= 0 }))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	fanout.WriteTracked(-1)
+	fanout.WriteTracked(0)
+	fanout.CreateConsumer(ctx)
+	fanout.WriteTracked(1)
+
+	stats := fanout.StatsAndReset()
+	if stats.Filtered != 1 || stats.QueueLatencyMax == 0 {
+		t.Errorf("StatsAndReset returned %+v, expected the counters so far", stats)
+	}
+	if counts := fanout.DropCounts(); counts[DropNoConsumers] != 0 {
+		t.Errorf("DropCounts[DropNoConsumers] is %d after StatsAndReset, expected 0", counts[DropNoConsumers])
+	}
+	if stats := fanout.Stats(); stats != (ProducerStats{}) {
+		t.Errorf("Stats returned %+v after StatsAndReset, expected zero", stats)
+	}
+
+	fanout.WriteTracked(-2)
+	if stats := fanout.StatsAndReset(); stats.Filtered != 1 {
+		t.Errorf("Filtered is %d in the second interval, expected 1", stats.Filtered)
+	}
+}
+
+func TestSelectionAttempts(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 1)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	fanout.CreateConsumer(ctx)
+	fanout.CreateConsumer(ctx)
+
+	// The second item finds both buffers full
+	fanout.WriteTracked(0)
+	fanout.WriteTracked(1)
+
+	stats := fanout.Stats()
+	if stats.SelectionAttempts != 4 || stats.SelectionAccepted != 2 || stats.AttemptsPerDelivery != 2 {
+		t.Errorf("Stats returned %d attempts, %d accepted, %v per delivery, expected 4, 2 and 2",
+			stats.SelectionAttempts, stats.SelectionAccepted, stats.AttemptsPerDelivery)
+	}
+}
+
+func TestLockTiming(t *testing.T) {
+	slow := WithInputFilter(func(item int) bool {
+		time.Sleep(5 * time.Millisecond)
+		return true
+	})
+	// One item per lock hold, so the two writes are two holds
+	fanout := NewProducer[int](ProducerKind_All, 16, 16, slow, WithLockTiming[int](), WithDispatchBatch[int](1))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	fanout.CreateConsumer(ctx)
+	fanout.WriteTracked(1)
+	fanout.WriteTracked(2)
+
+	// WriteTracked returns before dispatch releases the lock, which is when the hold is recorded
+	deadline := time.Now().Add(time.Second)
+	for fanout.Stats().LockHolds < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stats := fanout.StatsAndReset()
+	if stats.LockHolds != 2 {
+		t.Errorf("LockHolds is %d, expected 2", stats.LockHolds)
+	}
+	if stats.LockHoldMax < 5*time.Millisecond || stats.LockHoldAvg < 5*time.Millisecond || stats.LockHoldAvg > stats.LockHoldMax {
+		t.Errorf("Lock held %v avg, %v max, expected at least 5ms", stats.LockHoldAvg, stats.LockHoldMax)
+	}
+	if stats := fanout.Stats(); stats.LockHolds != 0 || stats.LockHoldMax != 0 {
+		t.Errorf("Lock timing was not reset: %d holds, %v max", stats.LockHolds, stats.LockHoldMax)
+	}
+
+	untimed := NewProducer[int](ProducerKind_All, 16, 16)
+	defer untimed.Close()
+	untimed.CreateConsumer(ctx)
+	untimed.WriteTracked(1)
+	if stats := untimed.Stats(); stats.LockHolds != 0 || stats.LockHoldAvg != 0 || stats.LockHoldMax != 0 {
+		t.Errorf("Lock timing measured without WithLockTiming: %+v", stats)
+	}
+}
@@ -0,0 +1,108 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type traceKey struct{}
+
+type recordedSpan struct {
+	parent     any
+	strategy   ProducerKind
+	consumerID string
+	delivered  int
+}
+
+type recordingTracer struct {
+	spans chan *recordedSpan
+}
+
+func (r recordingTracer) StartDispatch(ctx context.Context, strategy ProducerKind) DispatchSpan {
+	return &recordingSpan{tracer: r, span: &recordedSpan{parent: ctx.Value(traceKey{}), strategy: strategy}}
+}
+
+type recordingSpan struct {
+	tracer recordingTracer
+	span   *recordedSpan
+}
+
+func (s *recordingSpan) End(consumerID string, delivered int) {
+	s.span.consumerID, s.span.delivered = consumerID, delivered
+	s.tracer.spans <- s.span
+}
+
+func TestDispatchTracer(t *testing.T) {
+	tracer := recordingTracer{spans: make(chan *recordedSpan, 4)}
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16, WithDispatchTracer[int](tracer))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	traced := context.WithValue(ctx, traceKey{}, "parent")
+
+	next := func() recordedSpan {
+		select {
+		case span := <-tracer.spans:
+			return *span
+		case <-ctx.Done():
+			t.Fatal("Missing span")
+		}
+		return recordedSpan{}
+	}
+
+	fanout.WriteTraced(traced, 0)
+	if span, expected := next(), (recordedSpan{parent: "parent", strategy: ProducerKind_LRU}); span != expected {
+		t.Errorf("Dropped span is %+v, expected %+v", span, expected)
+	}
+
+	consumer := fanout.CreateConsumer(ctx)
+	fanout.WriteTraced(traced, 1)
+	expected := recordedSpan{parent: "parent", strategy: ProducerKind_LRU, consumerID: consumer.Id(), delivered: 1}
+	if span := next(); span != expected {
+		t.Errorf("Delivered span is %+v, expected %+v", span, expected)
+	}
+
+	fanout.WriteTracked(2)
+	if len(tracer.spans) != 0 {
+		t.Error("Untraced write started a span")
+	}
+}
+
+func TestDispatchTracerRoutesAndStartup(t *testing.T) {
+	tracer := recordingTracer{spans: make(chan *recordedSpan, 4)}
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16, WithDispatchTracer[int](tracer), WithStartupBuffer[int](4))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	// A held item's span stays open until the first consumer flushes it
+	fanout.WriteTraced(ctx, 0)
+	fanout.WriteTracked(1)
+	if len(tracer.spans) != 0 {
+		t.Fatal("Span of a held item ended before it was flushed")
+	}
+	consumer := fanout.CreateConsumer(ctx)
+	select {
+	case span := <-tracer.spans:
+		if span.consumerID != consumer.Id() || span.delivered != 1 {
+			t.Errorf("Flushed span is %+v, expected delivery to %s", *span, consumer.Id())
+		}
+	case <-ctx.Done():
+		t.Fatal("Span of a held item did not end when it was flushed")
+	}
+
+	// Routed items report the route's strategy
+	fanout.AddRoute("all", ProducerKind_All, []string{consumer.Id()})
+	fanout.write(envelope[int]{item: 2, route: "all", ctx: ctx})
+	select {
+	case span := <-tracer.spans:
+		if span.strategy != ProducerKind_All {
+			t.Errorf("Routed span has strategy %v, expected %v", span.strategy, ProducerKind_All)
+		}
+	case <-ctx.Done():
+		t.Fatal("Missing span of the routed item")
+	}
+}
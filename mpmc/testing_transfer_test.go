@@ -0,0 +1,81 @@
+package mpmc
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTransferBuffer(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 4)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	// LRU fills the source with 0 to 3 and delivers 4 to the destination
+	from := fanout.CreateConsumer(ctx)
+	for i := 0; i < 3; i++ {
+		fanout.WriteTracked(i)
+	}
+	to := fanout.CreateConsumer(ctx)
+	fanout.WriteTracked(3)
+	fanout.WriteTracked(4)
+
+	if _, err := fanout.TransferBuffer(from.Id(), "missing"); err != ErrUnknownConsumer {
+		t.Errorf("TransferBuffer to a missing consumer returned %v, expected ErrUnknownConsumer", err)
+	}
+
+	moved, err := fanout.TransferBuffer(from.Id(), to.Id())
+	if err != nil || moved != 3 {
+		t.Errorf("TransferBuffer returned %d, %v, expected 3 items moved into the remaining room", moved, err)
+	}
+	if counts := fanout.DropCounts(); counts[DropConsumerFull] != 1 {
+		t.Errorf("DropCounts[DropConsumerFull] is %d, expected the item that did not fit", counts[DropConsumerFull])
+	}
+	if got, expected := to.DrainBuffered(), []int{4, 0, 1, 2}; !slices.Equal(got, expected) {
+		t.Errorf("Destination holds %v, expected %v", got, expected)
+	}
+
+	// The source is decommissioned
+	select {
+	case <-from.ctx.Done():
+	case <-ctx.Done():
+		t.Error("Source consumer was not closed")
+	}
+	if id, _ := fanout.WriteTracked(5); id != to.Id() {
+		t.Errorf("Item delivered to %q after the transfer, expected the destination", id)
+	}
+}
+
+func TestTransferBufferAdaptive(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 0, WithAdaptiveBuffer[int](2, 16))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	from := fanout.CreateConsumer(ctx)
+	for i := 0; i < 8; i++ {
+		fanout.WriteTracked(i)
+	}
+	to := fanout.CreateConsumer(ctx)
+
+	if moved, err := fanout.TransferBuffer(from.Id(), to.Id()); err != nil || moved != 8 {
+		t.Fatalf("TransferBuffer returned %d, %v, expected 8 items moved", moved, err)
+	}
+	got := make([]int, 0, 8)
+	for len(got) < 8 {
+		select {
+		case item := <-to.Messages:
+			got = append(got, item)
+		case <-ctx.Done():
+			t.Fatalf("Destination received %v, expected 8 items", got)
+		}
+	}
+	slices.Sort(got)
+	if expected := []int{0, 1, 2, 3, 4, 5, 6, 7}; !slices.Equal(got, expected) {
+		t.Errorf("Destination received %v, expected %v", got, expected)
+	}
+}
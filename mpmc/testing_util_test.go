@@ -0,0 +1,51 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("entropy unavailable")
+}
+
+func TestCreateIDFallback(t *testing.T) {
+	saved := randReader
+	randReader = failingReader{}
+	defer func() { randReader = saved }()
+
+	if _, err := createID(); err == nil {
+		t.Fatal("createID succeeded with a failing reader")
+	}
+
+	a, b := CreateID(), CreateID()
+	if len(a) != 36 || a == b {
+		t.Errorf("CreateID returned %q and %q, expected two distinct UUID-like IDs", a, b)
+	}
+
+	fanout := NewProducer[int](ProducerKind_Single, 1, 1)
+	defer fanout.Close()
+	if consumer := fanout.CreateConsumer(context.Background()); len(consumer.Id()) != 36 {
+		t.Errorf("Consumer ID is %q, expected a UUID-like fallback ID", consumer.Id())
+	}
+}
+
+func TestWithIDGenerator(t *testing.T) {
+	next := 0
+	fanout := NewProducer[int](ProducerKind_Single, 1, 1, WithIDGenerator[int](func() string {
+		id := fmt.Sprintf("c%d", next)
+		next++
+		return id
+	}))
+	defer fanout.Close()
+
+	for _, expected := range []string{"c0", "c1"} {
+		if consumer := fanout.CreateConsumer(context.Background()); consumer.Id() != expected {
+			t.Errorf("Consumer ID is %q, expected %q", consumer.Id(), expected)
+		}
+	}
+}
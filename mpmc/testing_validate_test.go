@@ -0,0 +1,41 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValidator(t *testing.T) {
+	negative := errors.New("negative item")
+	fanout := NewProducer[int](ProducerKind_All, 16, 16, WithValidator(func(item int) error {
+		if item < 0 {
+			return negative
+		}
+		return nil
+	}))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	if err := fanout.Write(-1); err != negative {
+		t.Errorf("Write returned %v for an invalid item, expected %v", err, negative)
+	}
+	if err := fanout.WriteRetry(-2); err != negative {
+		t.Errorf("WriteRetry returned %v for an invalid item, expected %v", err, negative)
+	}
+	if _, err := fanout.WriteTracked(1); err != nil {
+		t.Errorf("WriteTracked returned %v for a valid item", err)
+	}
+
+	if got, expected := consumer.DrainBuffered(), []int{1}; !slices.Equal(got, expected) {
+		t.Errorf("Consumer received %v, expected only the valid items %v", got, expected)
+	}
+	if invalid := fanout.DropCounts()[DropInvalid]; invalid != 2 {
+		t.Errorf("Expected 2 invalid items, got %d", invalid)
+	}
+}
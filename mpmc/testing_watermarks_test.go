@@ -0,0 +1,51 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInputWatermarks(t *testing.T) {
+	crossings := make(chan string, 8)
+	fanout := NewProducer[int](ProducerKind_LRU, 10, 64, WithInputWatermarks[int](2, 6,
+		func() { crossings <- "high" },
+		func() { crossings <- "low" }))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	fanout.CreateConsumer(ctx)
+
+	expect := func(expected string) {
+		t.Helper()
+		select {
+		case crossing := <-crossings:
+			if crossing != expected {
+				t.Fatalf("Crossed the %s watermark, expected %s", crossing, expected)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Missing crossing of the %s watermark", expected)
+		}
+	}
+
+	for round := 0; round < 2; round++ {
+		fanout.Pause()
+		for i := 0; i < 5; i++ {
+			fanout.Write(i)
+		}
+		if len(crossings) != 0 {
+			t.Fatal("Crossed a watermark below the high watermark")
+		}
+		fanout.Write(5)
+		fanout.Write(6)
+		expect("high")
+
+		fanout.Resume()
+		expect("low")
+		fanout.WriteTracked(7)
+		if len(crossings) != 0 {
+			t.Fatalf("Crossed the %s watermark again", <-crossings)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFanoutWeightedLRU(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_WeightedLRU, 65535, 65535)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	light := fanout.CreateConsumerWeighted(ctx, 1)
+	heavy := fanout.CreateConsumerWeighted(ctx, 3)
+	unused := fanout.CreateConsumerWeighted(ctx, 0)
+
+	numItems := 4000
+	for i := 0; i < numItems; i++ {
+		if _, err := fanout.WriteTracked(i); err != nil {
+			t.Fatalf("WriteTracked returned %v", err)
+		}
+	}
+
+	if len(unused.Messages) != 0 {
+		t.Errorf("Consumer with weight 0 received %d items, expected 0", len(unused.Messages))
+	}
+	if len(light.Messages)+len(heavy.Messages) != numItems {
+		t.Fatalf("Consumers received %d items, expected %d", len(light.Messages)+len(heavy.Messages), numItems)
+	}
+
+	ratio := float64(len(heavy.Messages)) / float64(len(light.Messages))
+	if ratio < 2 || ratio > 4 {
+		t.Errorf("Heavy consumer received %d items and light consumer %d, ratio %.2f, expected around 3",
+			len(heavy.Messages), len(light.Messages), ratio)
+	}
+}
+
+func TestFanoutSmoothWeighted(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_SmoothWeighted, 64, 64)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	a := fanout.CreateConsumerWeighted(ctx, 5)
+	b := fanout.CreateConsumerWeighted(ctx, 1)
+	c := fanout.CreateConsumerWeighted(ctx, 1)
+	names := map[string]string{a.Id(): "a", b.Id(): "b", c.Id(): "c"}
+
+	pattern := ""
+	for i := 0; i < 14; i++ {
+		id, err := fanout.WriteTracked(i)
+		if err != nil {
+			t.Fatalf("WriteTracked returned %v", err)
+		}
+		pattern += names[id]
+	}
+
+	// The nginx sequence for weights {5, 1, 1}, repeated
+	if expected := "aabacaaaabacaa"; pattern != expected {
+		t.Errorf("Delivery pattern is %s, expected %s", pattern, expected)
+	}
+}
+
+func TestFanoutInverseFullness(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_InverseFullness, 64, 100)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	// drained is emptied after every write, full keeps everything it receives
+	drained := fanout.CreateConsumer(ctx)
+	full := fanout.CreateConsumer(ctx)
+	toDrained := 0
+	for i := 0; i < 200; i++ {
+		if _, err := fanout.WriteTracked(i); err != nil {
+			t.Fatalf("WriteTracked returned %v", err)
+		}
+		toDrained += len(drained.DrainBuffered())
+	}
+
+	toFull := len(full.Messages)
+	if toDrained+toFull != 200 {
+		t.Fatalf("Consumers received %d items, expected 200", toDrained+toFull)
+	}
+	// Expected about 130 and 70; the margin makes a spurious failure practically impossible
+	if toDrained < toFull+30 {
+		t.Errorf("Emptier consumer received %d items and the filling one %d, expected the emptier one to receive clearly more", toDrained, toFull)
+	}
+}
+
+func TestFanoutWeighted(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_Weighted, 64, 64)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	// A consumer with weight 0 receives items while it is the only consumer
+	idle := fanout.CreateConsumerWeighted(ctx, 0)
+	if id, _ := fanout.WriteTracked(0); id != idle.Id() {
+		t.Errorf("Item went to %q, expected the only consumer %s", id, idle.Id())
+	}
+	idle.DrainBuffered()
+
+	fast := fanout.CreateConsumerWeighted(ctx, 3)
+	slow := fanout.CreateConsumerWeighted(ctx, 1)
+	for i := 0; i < 40; i++ {
+		fanout.WriteTracked(i)
+	}
+	if len(idle.Messages) != 0 || len(fast.Messages) != 30 || len(slow.Messages) != 10 {
+		t.Errorf("Weights 0, 3 and 1 received %d, %d and %d items, expected 0, 30 and 10",
+			len(idle.Messages), len(fast.Messages), len(slow.Messages))
+	}
+}
+
+func TestFanoutWeightedAfterRemoval(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_Weighted, 64, 64)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	idle := fanout.CreateConsumerWeighted(ctx, 0)
+	fast := fanout.CreateConsumerWeighted(ctx, 3)
+	slow := fanout.CreateConsumerWeighted(ctx, 1)
+	for i := 0; i < 3; i++ {
+		fanout.WriteTracked(i)
+	}
+
+	// Removing a consumer mid-rotation must not hand items to the weight-0 consumer
+	fanout.RemoveConsumer(fast.Id())
+	for i := 3; i < 10; i++ {
+		if id, _ := fanout.WriteTracked(i); id != slow.Id() {
+			t.Fatalf("Item %d went to %q, expected the only positively weighted consumer %s", i, id, slow.Id())
+		}
+	}
+	if len(idle.Messages) != 0 {
+		t.Errorf("Consumer with weight 0 received %d items", len(idle.Messages))
+	}
+}
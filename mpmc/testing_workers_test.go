@@ -0,0 +1,62 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunWorkers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var sum atomic.Int64
+	fanout, wait := RunWorkers(ctx, ProducerKind_LRU, 4, func(item int) error {
+		time.Sleep(100 * time.Microsecond)
+		sum.Add(int64(item))
+		return nil
+	})
+	for i := 1; i <= 100; i++ {
+		if _, err := fanout.WriteTracked(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fanout.CloseDraining(ctx)
+	if err := wait(); err != nil {
+		t.Errorf("wait returned %v after the Producer closed", err)
+	}
+	if sum.Load() != 5050 {
+		t.Errorf("Workers handled items summing to %d, expected 5050", sum.Load())
+	}
+}
+
+func TestRunWorkersError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	failed := errors.New("handler failed")
+	fanout, wait := RunWorkers(ctx, ProducerKind_LRU, 4, func(item int) error {
+		if item == 3 {
+			return failed
+		}
+		return nil
+	})
+	for i := 0; i < 4; i++ {
+		fanout.Write(i)
+	}
+
+	if err := wait(); err != failed {
+		t.Errorf("wait returned %v, expected the handler error", err)
+	}
+	select {
+	case <-fanout.closed:
+	case <-ctx.Done():
+		t.Fatal("Producer was not closed after the handler error")
+	}
+	if err := fanout.Write(4); err != ErrProducerClosed {
+		t.Errorf("Write returned %v after the handler error, expected ErrProducerClosed", err)
+	}
+}
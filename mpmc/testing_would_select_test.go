@@ -0,0 +1,39 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWouldSelect(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	if _, ok := fanout.WouldSelect(1); ok {
+		t.Error("WouldSelect reported a target without consumers")
+	}
+
+	first := fanout.CreateConsumer(ctx)
+	second := fanout.CreateConsumer(ctx)
+
+	// Asking repeatedly does not use up the least recently used consumer
+	for i := 0; i < 3; i++ {
+		if id, ok := fanout.WouldSelect(1); !ok || id != first.Id() {
+			t.Errorf("WouldSelect returned %q, %v, expected %s", id, ok, first.Id())
+		}
+	}
+	if len(first.Messages) != 0 {
+		t.Error("WouldSelect delivered the item")
+	}
+
+	if id, _ := fanout.WriteTracked(1); id != first.Id() {
+		t.Errorf("Item went to %s, expected the selected consumer %s", id, first.Id())
+	}
+	if id, ok := fanout.WouldSelect(2); !ok || id != second.Id() {
+		t.Errorf("WouldSelect returned %q, %v after a delivery, expected %s", id, ok, second.Id())
+	}
+}
@@ -0,0 +1,367 @@
+package mpmc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWriteWhenReady(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_Single, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- fanout.WriteWhenReady(ctx, 42)
+	}()
+
+	// Give the writer a chance to start waiting before a consumer attaches
+	time.Sleep(50 * time.Millisecond)
+	consumer := fanout.CreateConsumer(ctx)
+
+	if err := <-errs; err != nil {
+		t.Fatalf("WriteWhenReady returned %v", err)
+	}
+
+	select {
+	case item := <-consumer.Messages:
+		if item != 42 {
+			t.Errorf("Consumer received %d, expected 42", item)
+		}
+	case <-ctx.Done():
+		t.Fatal("Consumer did not receive the item")
+	}
+}
+
+func TestWriteWhenReadyConsumerDetaches(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_Single, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	// The consumer detaches after the wait but before the item is dispatched
+	fanout.Pause()
+	leaving := fanout.CreateConsumer(ctx)
+	if err := fanout.WriteWhenReady(ctx, 42); err != nil {
+		t.Fatalf("WriteWhenReady returned %v", err)
+	}
+	fanout.RemoveConsumer(leaving.Id())
+	fanout.Resume()
+
+	// A plain write dispatched after the item is dropped, so the item has been dispatched too
+	fanout.WriteTracked(0)
+	if dropped := fanout.DropCounts()[DropNoConsumers]; dropped != 1 {
+		t.Errorf("%d items dropped without consumers, expected only the plain write", dropped)
+	}
+
+	consumer := fanout.CreateConsumer(ctx)
+	select {
+	case item := <-consumer.Messages:
+		if item != 42 {
+			t.Errorf("Consumer received %d, expected 42", item)
+		}
+	case <-ctx.Done():
+		t.Fatal("The held item was not delivered to the next consumer")
+	}
+
+	// An item still held when the Producer closes is discarded
+	fanout.Pause()
+	if err := fanout.WriteWhenReady(ctx, 43); err != nil {
+		t.Fatalf("WriteWhenReady returned %v", err)
+	}
+	fanout.RemoveConsumer(consumer.Id())
+	fanout.Resume()
+	fanout.WriteTracked(0)
+	if err := fanout.ShutdownOrdered(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if dropped := fanout.DropCounts()[DropClosed]; dropped != 1 {
+		t.Errorf("%d items discarded on close, expected the held item", dropped)
+	}
+}
+
+func TestWriteWhenReadyContextCancelled(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_Single, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := fanout.WriteWhenReady(ctx, 42); err != context.DeadlineExceeded {
+		t.Errorf("WriteWhenReady returned %v, expected %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestWriteAsInFlightLimit(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_Single, 16, 16, WithMaxInFlightPerCaller[int](2))
+	defer fanout.Close()
+
+	// Stall the dispatch goroutine so written items stay in flight
+	fanout.consumers_mu.Lock()
+
+	if err := fanout.WriteAs("a", 1); err != nil {
+		t.Fatalf("WriteAs returned %v", err)
+	}
+	// Let the dispatch goroutine pick up the first item and block on the mutex
+	time.Sleep(50 * time.Millisecond)
+
+	for i := 2; i <= 3; i++ {
+		if err := fanout.WriteAs("a", i); err != nil {
+			t.Fatalf("WriteAs returned %v", err)
+		}
+	}
+	if err := fanout.WriteAs("a", 4); err != ErrCallerLimit {
+		t.Errorf("WriteAs returned %v, expected %v", err, ErrCallerLimit)
+	}
+	if err := fanout.WriteAs("b", 5); err != nil {
+		t.Errorf("WriteAs for another caller returned %v", err)
+	}
+
+	fanout.consumers_mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+
+	if err := fanout.WriteAs("a", 6); err != nil {
+		t.Errorf("WriteAs after dispatch returned %v", err)
+	}
+}
+
+func TestAddInput(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+
+	a := make(chan int)
+	b := make(chan int)
+	stopA := fanout.AddInput(a)
+	stopB := fanout.AddInput(b)
+	defer stopB()
+
+	a <- 1
+	b <- 2
+
+	received := map[int]bool{}
+	for len(received) < 2 {
+		select {
+		case item := <-consumer.Messages:
+			received[item] = true
+		case <-ctx.Done():
+			t.Fatalf("Consumer received %v, expected items from both inputs", received)
+		}
+	}
+
+	stopA()
+	stopA()
+
+	select {
+	case a <- 3:
+		t.Error("Stopped input is still being read")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWriteTracked(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_LRU, 16, 16, WithFallback[int](ProducerKind_All))
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	if id, err := fanout.WriteTracked(0); err != nil || id != "" {
+		t.Errorf("WriteTracked without consumers returned %q, %v, expected a drop", id, err)
+	}
+
+	// An unbuffered channel nobody reads never accepts an item, so every item must take the fallback
+	fanout.AddOutputChannel(make(chan int))
+	consumer := fanout.CreateConsumer(ctx)
+	if err := fanout.WaitForConsumers(ctx, 2); err != nil {
+		t.Fatalf("WaitForConsumers returned %v", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		id, err := fanout.WriteTracked(i)
+		if err != nil {
+			t.Fatalf("WriteTracked returned %v", err)
+		}
+		if id != consumer.Id() {
+			t.Errorf("Item %d went to %q, expected %q", i, id, consumer.Id())
+		}
+	}
+}
+
+func TestStartSequence(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16, WithStartSequence[int](100))
+	defer fanout.Close()
+
+	consumer := fanout.CreateConsumer(context.Background())
+	if fanout.Sequence() != 100 {
+		t.Errorf("Sequence is %d, expected 100", fanout.Sequence())
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := fanout.WriteTracked(i); err != nil {
+			t.Fatalf("WriteTracked returned %v", err)
+		}
+	}
+
+	if fanout.Sequence() != 103 {
+		t.Errorf("Sequence is %d, expected 103", fanout.Sequence())
+	}
+	if consumer.LastSequence() != 103 {
+		t.Errorf("Consumer last sequence is %d, expected 103", consumer.LastSequence())
+	}
+}
+
+func TestWriteToLRU(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_Single, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumers := make([]*Consumer[int], 4)
+	for i := range consumers {
+		consumers[i] = fanout.CreateConsumer(ctx)
+	}
+
+	for i := 0; i < 2; i++ {
+		if delivered := fanout.WriteToLRU(i, 2); delivered != 2 {
+			t.Errorf("WriteToLRU delivered to %d consumers, expected 2", delivered)
+		}
+	}
+	// Two replicated writes rotate through all four consumers
+	for i, consumer := range consumers {
+		if len(consumer.Messages) != 1 {
+			t.Errorf("Consumer %d holds %d items, expected 1", i, len(consumer.Messages))
+		}
+	}
+	// Picking the least recently used consumers leaves the Producer's list in attach order
+	for i, info := range fanout.ConsumerInfo() {
+		if info.ID != consumers[i].Id() {
+			t.Errorf("Consumer %d in ConsumerInfo is %s, expected %s", i, info.ID, consumers[i].Id())
+		}
+	}
+
+	if delivered := fanout.WriteToLRU(2, 10); delivered != len(consumers) {
+		t.Errorf("WriteToLRU delivered to %d consumers, expected %d", delivered, len(consumers))
+	}
+}
+
+func TestWriteTicker(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 16, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	before := fanout.GoroutineCount()
+
+	next := 0
+	stop := fanout.WriteTicker(ctx, 5*time.Millisecond, func() int {
+		next++
+		return next
+	})
+
+	for i := 1; i <= 3; i++ {
+		select {
+		case item := <-consumer.Messages:
+			if item != i {
+				t.Errorf("Received %d, expected %d", item, i)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Ticker wrote only %d items", i-1)
+		}
+	}
+
+	stop()
+	stop()
+	for fanout.GoroutineCount() != before {
+		select {
+		case <-ctx.Done():
+			t.Fatal("Ticker goroutine did not stop")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestUnboundedInput(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_All, 4, 100000, WithUnboundedInput[int]())
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	consumer := fanout.CreateConsumer(ctx)
+
+	// Paused, so every item has to wait in the input
+	fanout.Pause()
+	numItems := 100000
+	for i := 0; i < numItems; i++ {
+		if err := fanout.Write(i); err != nil {
+			t.Fatalf("Write %d returned %v", i, err)
+		}
+	}
+	fanout.Resume()
+
+	for i := 0; i < numItems; i++ {
+		select {
+		case item := <-consumer.Messages:
+			if item != i {
+				t.Fatalf("Received %d, expected %d", item, i)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Received only %d items", i)
+		}
+	}
+}
+
+func TestWriteContext(t *testing.T) {
+	fanout := NewProducer[int](ProducerKind_Single, 1, 16)
+	defer fanout.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	consumer := fanout.CreateConsumer(ctx)
+	fanout.Pause()
+	if err := fanout.WriteContext(ctx, 0); err != nil {
+		t.Fatalf("WriteContext returned %v with room in the input buffer", err)
+	}
+
+	short, stop := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer stop()
+	if err := fanout.WriteContext(short, 1); err != context.DeadlineExceeded {
+		t.Errorf("WriteContext returned %v with a full input buffer, expected %v", err, context.DeadlineExceeded)
+	}
+
+	// A blocked write completes once dispatch makes room
+	result := make(chan error, 1)
+	go func() { result <- fanout.WriteContext(ctx, 2) }()
+	time.Sleep(10 * time.Millisecond)
+	fanout.Resume()
+	if err := <-result; err != nil {
+		t.Errorf("WriteContext returned %v after dispatch resumed", err)
+	}
+	for _, expected := range []int{0, 2} {
+		if item := <-consumer.Messages; item != expected {
+			t.Errorf("Received %d, expected %d", item, expected)
+		}
+	}
+
+	// A blocked write fails once the Producer closes
+	fanout.Pause()
+	fanout.WriteContext(ctx, 3)
+	go func() { result <- fanout.WriteContext(ctx, 4) }()
+	time.Sleep(10 * time.Millisecond)
+	fanout.Close()
+	if err := <-result; err != ErrProducerClosed {
+		t.Errorf("WriteContext returned %v when the Producer closed, expected %v", err, ErrProducerClosed)
+	}
+}
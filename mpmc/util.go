@@ -1,19 +1,49 @@
 package mpmc
 
 import (
-	"crypto/rand"
+	crand "crypto/rand"
+	"encoding/binary"
 	"fmt"
+	"io"
+	"math/rand"
 	"strings"
+	"time"
 )
 
+// randReader is the source of random bytes for IDs, replaceable in tests.
+var randReader io.Reader = crand.Reader
+
+// CreateID returns a random UUID-like identifier.
+// If the system's secure random source fails it falls back to a weaker,
+// time-seeded identifier instead of failing.
 func CreateID() string {
-	b := make([]byte, 16) // Generate 16 random bytes
-	_, err := rand.Read(b)
+	id, err := createID()
 	if err != nil {
-		panic("Error: unable to generate random bytes")
+		return createFallbackID()
 	}
+	return id
+}
+
+// createID returns a random UUID-like identifier from the secure random source.
+func createID() (string, error) {
+	b := make([]byte, 16) // Generate 16 random bytes
+	if _, err := io.ReadFull(randReader, b); err != nil {
+		return "", err
+	}
+	return formatID(b), nil
+}
+
+// createFallbackID returns a UUID-like identifier built from the current time and math/rand.
+// It is unique enough to tell consumers apart but must not be relied on for security.
+func createFallbackID() string {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b[0:8], uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint64(b[8:16], rand.Uint64())
+	return formatID(b)
+}
 
-	// Convert random bytes to a UUID-like string
+// formatID converts 16 bytes to a UUID-like string.
+func formatID(b []byte) string {
 	return fmt.Sprintf("%x-%x-%x-%x-%x",
 		b[0:4],   // 8 hex digits
 		b[4:6],   // 4 hex digits